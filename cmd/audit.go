@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query and ship the local audit log",
+	Long:  "Every ECS exec and port forwarding session is appended to a local JSONL audit log (~/.infra/audit.jsonl, or INFRA_AUDIT_LOG if set).",
+}
+
+var auditSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List audited exec and tunnel sessions",
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetDuration("since")
+		kind, _ := cmd.Flags().GetString("kind")
+		if err := functions.ListAuditSessions(since, kind); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var auditShipCmd = &cobra.Command{
+	Use:   "ship",
+	Short: "Upload the audit log to an S3 bucket",
+	Run: func(cmd *cobra.Command, args []string) {
+		bucket, _ := cmd.Flags().GetString("bucket")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		if err := functions.ShipAuditLog(bucket, prefix); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditSessionsCmd)
+	auditCmd.AddCommand(auditShipCmd)
+
+	auditSessionsCmd.Flags().Duration("since", 0, "Only show sessions started within this window (e.g. 24h)")
+	auditSessionsCmd.Flags().String("kind", "", "Only show sessions of this kind (ecs-exec, ecs-host-exec, ecs-portforward, ecs-container-portforward, ec2-portforward, ec2-eice-portforward, db-dump, db-restore)")
+
+	auditShipCmd.Flags().String("bucket", os.Getenv("INFRA_AUDIT_BUCKET"), "S3 bucket to ship the audit log to (defaults to INFRA_AUDIT_BUCKET)")
+	auditShipCmd.Flags().String("prefix", "infra-audit", "Key prefix within the bucket")
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Work with the databases inside RDS instances and Aurora clusters",
+	Long: `Interactively select your RDS instance or Aurora cluster and work with its databases through the same
+SSM tunnel as infra portforward, opened in the background and closed again when done, or through the
+RDS Data API with no tunnel.`,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+}
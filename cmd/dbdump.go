@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var dbDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump a database to S3 through an SSM tunnel",
+	Long: `Interactively select your RDS instance or Aurora cluster and an EC2 instance or ECS container to tunnel
+through, then dump a database with pg_dump or mysqldump and stream it gzip compressed to
+<--s3>/<identifier>/<database>-<yyyymmdd-hhmmss>.sql.gz, encrypted with SSE-KMS.
+
+Credentials come from --secret, or the master user secret RDS manages if there is one. Otherwise
+pg_dump or mysqldump prompts for the password, or reads it from PGPASSWORD or MYSQL_PWD.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		database, _ := cmd.Flags().GetString("database")
+		user, _ := cmd.Flags().GetString("user")
+		secret, _ := cmd.Flags().GetString("secret")
+		s3URI, _ := cmd.Flags().GetString("s3")
+		kmsKey, _ := cmd.Flags().GetString("kms-key")
+		if err := functions.ExecuteDBDump(database, user, secret, s3URI, kmsKey); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a database from a dump in S3 through an SSM tunnel",
+	Long: `Restore a dump made by infra db dump into a database with psql or mysql, through a tunnel as for dump.
+--from names the dump, or a prefix ending in / to select one of the dumps under it. The restore is
+confirmed first unless --auto-approve is passed; PostgreSQL dumps are applied in a single transaction.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		database, _ := cmd.Flags().GetString("database")
+		user, _ := cmd.Flags().GetString("user")
+		secret, _ := cmd.Flags().GetString("secret")
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		if err := functions.ExecuteDBRestore(from, database, user, secret, autoApprove); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbDumpCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+
+	dbDumpCmd.Flags().String("s3", "", "S3 prefix to write the dump under, e.g. s3://my-backups/adhoc")
+	dbDumpCmd.Flags().String("kms-key", "", "KMS key to encrypt the dump with (default the aws/s3 key)")
+
+	dbRestoreCmd.Flags().String("from", "", "S3 URI of the dump, or a prefix ending in / to select from")
+	dbRestoreCmd.Flags().BoolP("auto-approve", "a", false, "Restore without confirmation")
+
+	for _, c := range []*cobra.Command{dbDumpCmd, dbRestoreCmd} {
+		c.Flags().StringP("database", "d", "", "Database name (prompted for if not set)")
+		c.Flags().StringP("user", "u", "", "Database user (default the master user)")
+		c.Flags().String("secret", "", "Secrets Manager secret with the username and password")
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var dbQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Run SQL against an Aurora cluster through the RDS Data API",
+	Long: `Interactively select an Aurora cluster with the Data API enabled and a Secrets Manager secret to
+authenticate with, then run a statement (--sql) or a file of statements separated by semicolons (--file)
+with no tunnel. Result sets are printed as a table, or as JSON or CSV with --output.
+
+With --batch, the --sql statement is run once for each object in a JSON array of named parameters,
+referenced as :name in the statement. With --transaction, all statements run in one transaction that is
+committed at the end, or rolled back if any statement fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sql, _ := cmd.Flags().GetString("sql")
+		file, _ := cmd.Flags().GetString("file")
+		batch, _ := cmd.Flags().GetString("batch")
+		database, _ := cmd.Flags().GetString("database")
+		secret, _ := cmd.Flags().GetString("secret")
+		output, _ := cmd.Flags().GetString("output")
+		transaction, _ := cmd.Flags().GetBool("transaction")
+		if err := functions.ExecuteDBQuery(sql, file, batch, database, secret, output, transaction); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbQueryCmd)
+	dbQueryCmd.Flags().StringP("sql", "s", "", "SQL statement to run")
+	dbQueryCmd.Flags().StringP("file", "f", "", "File of SQL statements to run")
+	dbQueryCmd.Flags().String("batch", "", "JSON file with an array of parameter sets to run --sql with")
+	dbQueryCmd.Flags().StringP("database", "d", "", "Database name (default the cluster's default database)")
+	dbQueryCmd.Flags().String("secret", "", "Secrets Manager secret ARN to authenticate with (prompted for if not set)")
+	dbQueryCmd.Flags().StringP("output", "o", "table", "Output format: table, json or csv")
+	dbQueryCmd.Flags().Bool("transaction", false, "Run all statements in one transaction")
+}
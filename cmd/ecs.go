@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ecsCmd = &cobra.Command{
+	Use:   "ecs",
+	Short: "Work with ECS clusters, services and tasks",
+	Long: `Interactively select your ECS cluster, task, and container to exec into or port forward to.
+Tasks can be chosen from a service, a task definition family, or every task in the cluster.`,
+}
+
+func init() {
+	rootCmd.AddCommand(ecsCmd)
+}
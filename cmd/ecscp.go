@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsCpCmd = &cobra.Command{
+	Use:   "cp <src> <dst>",
+	Short: "Copy files and directories into and out of ECS containers",
+	Long: `Copy a file or directory between your machine and an ECS container over ECS exec. The container side is
+written as task:container:/path, and the cluster is selected interactively.
+
+The file is streamed as base64 and its SHA-256 checksum is verified on both ends, so the container needs
+base64, sha256sum and head (as provided by coreutils or busybox). Directories are copied as a tar archive,
+so the container also needs tar for them.
+
+	infra ecs cp 0123456789abcdef:app:/tmp/heap.hprof ./heap.hprof
+	infra ecs cp ./config.yml 0123456789abcdef:app:/etc/app/config.yml`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := functions.ExecuteECSCopy(args[0], args[1])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsCpCmd)
+}
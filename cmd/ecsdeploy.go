@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsDeployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Deploy new container images to an ECS service",
+	Long: `Interactively select your ECS cluster and service, then register a new revision of its current task definition
+with the images of the named containers replaced, and deploy it. The rollout is followed until the service is
+stable. If the deployment fails, the service is reverted to the previous revision and infra exits non-zero.
+
+Images are given as container=image, where image is a full tag or digest reference, or as container=:tag or
+container=@digest to keep the container's current repository and swap in a new tag or digest:
+
+  infra ecs deploy --image app=123456789012.dkr.ecr.ap-southeast-1.amazonaws.com/app:v1.4.2
+  infra ecs deploy --image app=repo/app@sha256:... --image worker=repo/worker:v1.4.2
+  infra ecs deploy --image app=:v1.4.3 --image worker=@sha256:...`,
+	Run: func(cmd *cobra.Command, args []string) {
+		images, _ := cmd.Flags().GetStringArray("image")
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteECSDeploy(images, autoApprove, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsDeployCmd)
+	ecsDeployCmd.Flags().StringArray("image", nil, "Container image to deploy as container=image, container=:tag or container=@digest (repeatable)")
+	ecsDeployCmd.Flags().BoolP("auto-approve", "a", false, "Skip the confirmation prompt")
+	ecsDeployCmd.Flags().Duration("timeout", 15*time.Minute, "How long to wait for the service to become stable")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsEnableExecCmd = &cobra.Command{
+	Use:   "enable-exec",
+	Short: "Turn on ECS exec for a service and roll it",
+	Long: `Interactively select your ECS cluster and service, enable execute command on it and force a new deployment.
+Progress is printed until the service is stable and the new tasks' exec agents report RUNNING.
+
+With --add-policy, the ssmmessages permissions ECS exec needs are also added to the task role as an inline policy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		addPolicy, _ := cmd.Flags().GetBool("add-policy")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		err := functions.ExecuteECSEnableExec(addPolicy, timeout)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsEnableExecCmd)
+	ecsEnableExecCmd.Flags().Bool("add-policy", false, "Add the ssmmessages permissions to the task role as an inline policy")
+	ecsEnableExecCmd.Flags().Duration("timeout", 15*time.Minute, "How long to wait for the service to become stable")
+}
@@ -10,11 +10,61 @@ import (
 )
 
 var ecsExecCmd = &cobra.Command{
-	Use:   "ecs exec",
+	Use:   "exec",
 	Short: "Execute shell commands in ECS containers",
-	Long:  `Interactively select your ECS cluster, service, task, and container to exec into with a shell session.`,
+	Long: `Interactively select your ECS cluster, task, and container to exec into with a shell session.
+Pre-flight checks are run before the session starts (see "infra ecs exec doctor").
+
+With --command, the command is run once without a local TTY instead. Its output is streamed to stdout
+and infra exits with the remote command's exit status, so it can be used from pipelines and runbooks.
+ECS exec always runs the remote command under a pseudo-terminal, so its stdout and stderr arrive merged
+on stdout. The prompts and infra's own status lines are printed to stderr.
+
+With --all-tasks, the command is run in the selected container of every task in the service. Each output
+line is prefixed with its task ID, and a summary of per-task exit codes is printed to stderr at the end.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		command, _ := cmd.Flags().GetString("command")
+		allTasks, _ := cmd.Flags().GetBool("all-tasks")
+		if allTasks {
+			if command == "" {
+				fmt.Fprintln(os.Stderr, "Error: --all-tasks requires --command")
+				os.Exit(1)
+			}
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			status, err := functions.ExecuteECSExecAllTasks(command, concurrency, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(status)
+		}
+		if command != "" {
+			status, err := functions.ExecuteECSExecCommand(command, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(status)
+		}
+
+		skipPreflight, _ := cmd.Flags().GetBool("skip-preflight")
+		err := functions.ExecuteECSExec(skipPreflight)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ecsExecDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check why ECS exec into a container might fail",
+	Long: `Interactively select your ECS cluster, task, and container, then check everything ECS exec depends on:
+the service and task enableExecuteCommand settings, the ExecuteCommandAgent status, the Fargate platform version,
+the task role's ssmmessages permissions, the cluster's KMS and logging settings, and the network path to SSM.
+Each failed check is printed with a suggested fix.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := functions.ExecuteECSExec()
+		err := functions.ExecuteECSExecDoctor()
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
@@ -23,5 +73,10 @@ var ecsExecCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.AddCommand(ecsExecCmd)
+	ecsCmd.AddCommand(ecsExecCmd)
+	ecsExecCmd.AddCommand(ecsExecDoctorCmd)
+	ecsExecCmd.Flags().Bool("skip-preflight", false, "Skip the pre-flight checks before opening a shell")
+	ecsExecCmd.Flags().StringP("command", "c", "", "Run this command once and exit with its status instead of opening a shell")
+	ecsExecCmd.Flags().Bool("all-tasks", false, "Run --command against every task in the selected service")
+	ecsExecCmd.Flags().Int("concurrency", 5, "Maximum number of tasks to run --command against at once with --all-tasks")
 }
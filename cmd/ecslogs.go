@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Tail the CloudWatch logs of an ECS container",
+	Long: `Interactively select your ECS cluster, task, and container, then print its CloudWatch logs.
+The log group and stream are derived from the container's awslogs configuration in the task definition.
+
+With --all-tasks, the selected container's logs from every task in the service are interleaved in
+timestamp order, with each line prefixed (and colour-coded on a terminal) by its task ID.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		since, _ := cmd.Flags().GetDuration("since")
+		filter, _ := cmd.Flags().GetString("filter")
+		allTasks, _ := cmd.Flags().GetBool("all-tasks")
+		if err := functions.ExecuteECSLogs(follow, since, filter, allTasks); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsLogsCmd)
+	ecsLogsCmd.Flags().BoolP("follow", "f", false, "Keep printing new log events until interrupted")
+	ecsLogsCmd.Flags().Duration("since", 10*time.Minute, "Print log events from this far back (e.g. 30m, 2h)")
+	ecsLogsCmd.Flags().String("filter", "", "CloudWatch Logs filter pattern to match events against")
+	ecsLogsCmd.Flags().Bool("all-tasks", false, "Interleave the container's logs from every task in the service")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsPortforwardCmd = &cobra.Command{
+	Use:   "portforward",
+	Short: "Port forward to a port on an ECS container",
+	Long: `Interactively select your ECS cluster, task, and container, then forward a local port to one of the container's own ports (for example a JMX or pprof endpoint) using SSM.
+
+	Ensure that ECS exec is enabled on the service, as the session is opened through the container's SSM agent.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := functions.ExecuteECSPortForwarding()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsPortforwardCmd)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsRestartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Force a new deployment of an ECS service and follow the rollout",
+	Long: `Interactively select your ECS cluster and service, then force a new deployment to replace every task.
+The rollout is followed until the service is stable, printing task replacement progress, why any new task
+stopped, and whether the deployment circuit breaker rolled the deployment back.
+
+infra exits with a non-zero status if the deployment fails, is rolled back, or does not finish within --timeout.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteECSRestart(autoApprove, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsRestartCmd)
+	ecsRestartCmd.Flags().BoolP("auto-approve", "a", false, "Skip the confirmation prompt")
+	ecsRestartCmd.Flags().Duration("timeout", 15*time.Minute, "How long to wait for the service to become stable")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run a one-off ECS task with a service's configuration",
+	Long: `Interactively select your ECS cluster and service, then run a one-off task using the service's task definition,
+launch type or capacity providers, subnets, security groups and assign-public-ip setting. Use it for database
+migrations and batch jobs.
+
+With --command, the selected container's command is overridden (run with sh -c). The container's logs are
+streamed to stdout until the task stops, and infra exits with the container's exit code. If the task has not
+stopped within --timeout, infra exits with an error and leaves the task running.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		command, _ := cmd.Flags().GetString("command")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		status, err := functions.ExecuteECSRun(command, timeout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		os.Exit(status)
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsRunCmd)
+	ecsRunCmd.Flags().StringP("command", "c", "", "Command to run in the container instead of its default command")
+	ecsRunCmd.Flags().Duration("timeout", time.Hour, "How long to wait for the task to stop")
+}
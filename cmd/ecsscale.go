@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsScaleCmd = &cobra.Command{
+	Use:   "scale",
+	Short: "Set the desired count of an ECS service",
+	Long: `Interactively select your ECS cluster and service, then set its desired task count and wait until that many
+tasks are running.
+
+If the service has an Application Auto Scaling target, its min and max capacity are pinned to the new count
+so scaling policies do not undo it. With --suspend, scaling activities are suspended instead and min/max are
+only widened to include the new count.
+
+The settings from before the first scale are saved under ~/.infra/scale, and --restore puts them back.
+The current and new settings are shown and confirmed first unless --auto-approve is passed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		count, _ := cmd.Flags().GetInt("count")
+		suspend, _ := cmd.Flags().GetBool("suspend")
+		restore, _ := cmd.Flags().GetBool("restore")
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteECSScale(count, suspend, restore, autoApprove, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsScaleCmd)
+	ecsScaleCmd.Flags().Int("count", -1, "Desired number of tasks")
+	ecsScaleCmd.Flags().Bool("suspend", false, "Suspend auto scaling instead of pinning its min and max to --count")
+	ecsScaleCmd.Flags().Bool("restore", false, "Restore the desired count and auto scaling settings saved before scaling")
+	ecsScaleCmd.Flags().BoolP("auto-approve", "a", false, "Skip the confirmation prompt")
+	ecsScaleCmd.Flags().Duration("timeout", 10*time.Minute, "How long to wait for the running count to match")
+}
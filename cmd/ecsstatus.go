@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the health of an ECS service",
+	Long: `Interactively select your ECS cluster and service, then print its deployments with their rollout state,
+the running, pending and desired task counts, the health of targets in attached target groups, the images of
+the task definitions in use, and the most recent service events.
+
+With --watch, the status is redrawn every --interval (at least 1s) until interrupted. A failed refresh is
+shown and retried on the next one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		events, _ := cmd.Flags().GetInt("events")
+		if err := functions.ExecuteECSStatus(watch, interval, events); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsStatusCmd)
+	ecsStatusCmd.Flags().BoolP("watch", "w", false, "Redraw the status until interrupted")
+	ecsStatusCmd.Flags().Duration("interval", 5*time.Second, "How often to redraw the status with --watch")
+	ecsStatusCmd.Flags().Int("events", 10, "Number of recent service events to show")
+}
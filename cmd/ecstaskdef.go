@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsTaskDefCmd = &cobra.Command{
+	Use:   "taskdef",
+	Short: "Inspect and compare ECS task definitions",
+}
+
+var ecsTaskDefDiffCmd = &cobra.Command{
+	Use:   "diff [from] [to]",
+	Short: "Compare two revisions of a task definition",
+	Long: `Compare two task definition revisions (family:revision or ARN) and print the differences in images,
+CPU/memory, environment variables, secret ARNs, port mappings and IAM roles.
+
+With no arguments, the selected service's running revision is compared against the latest revision of
+its family. With one argument, that revision is compared against the latest revision of its family.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := functions.ExecuteECSTaskDefDiff(args); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ecsTaskDefEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the environment of an ECS container",
+	Long: `Interactively select your ECS cluster, task, and container, then print its environment variables as ECS
+resolves them, including the task's overrides. Secrets from SSM Parameter Store and Secrets Manager are
+shown with their source but redacted, unless --reveal is passed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		if err := functions.ExecuteECSTaskDefEnv(reveal); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsTaskDefCmd)
+	ecsTaskDefCmd.AddCommand(ecsTaskDefDiffCmd)
+	ecsTaskDefCmd.AddCommand(ecsTaskDefEnvCmd)
+	ecsTaskDefEnvCmd.Flags().Bool("reveal", false, "Fetch and print the values of secrets")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var ecsTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List the tasks of a service, task family or cluster",
+	Long: `Interactively select your ECS cluster and a task scope (a service, a task definition family, or all tasks),
+then list its running tasks, including scheduled and standalone tasks.
+
+With --stopped, recently stopped tasks are listed instead with their start and stop times, stop code,
+stopped reason and container exit codes, to help investigate crash loops. ECS keeps stopped tasks for
+about an hour.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		stopped, _ := cmd.Flags().GetBool("stopped")
+		if err := functions.ExecuteECSTasks(stopped); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ecsCmd.AddCommand(ecsTasksCmd)
+	ecsTasksCmd.Flags().Bool("stopped", false, "List recently stopped tasks with their stop reasons and exit codes")
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var rdsCmd = &cobra.Command{
+	Use:   "rds",
+	Short: "Work with RDS instances and Aurora clusters",
+	Long: `Interactively select your RDS instance or Aurora cluster to snapshot, copy and restore it or look into
+its performance, compare two of them, or report on every instance and cluster in a region.
+Snapshots handle Aurora clusters as a whole; their member instances are not listed separately.`,
+}
+
+func init() {
+	rootCmd.AddCommand(rdsCmd)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var rdsDiffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare two RDS instances, clusters or parameter groups",
+	Long: `Compare two RDS instances, Aurora clusters or parameter groups, possibly in different regions or
+accounts: engine version, instance class, storage, backup settings, and every parameter that differs
+between them or from the engine default.
+
+Each side is [[profile:]region:]name; a profile and region are only prompted for when a side leaves them out.
+Names are looked up as an instance, a cluster, a parameter group and a cluster parameter group, in that order.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := functions.ExecuteRDSDiff(args[0], args[1]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rdsCmd.AddCommand(rdsDiffCmd)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var rdsInsightsCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "Report on RDS Performance Insights",
+}
+
+var rdsInsightsTopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show the top SQL, wait events and users by DB load",
+	Long: `Interactively select your RDS instance, including Aurora cluster members, and print the top SQL
+statements by DB load from Performance Insights over the last --since, with the wait events each spends
+its load on, followed by the top wait events and database users.
+
+DB load is measured in average active sessions. Statements are shortened to one line unless --full is passed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetDuration("since")
+		limit, _ := cmd.Flags().GetInt("limit")
+		full, _ := cmd.Flags().GetBool("full")
+		if err := functions.ExecuteRDSInsightsTop(since, limit, full); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rdsCmd.AddCommand(rdsInsightsCmd)
+	rdsInsightsCmd.AddCommand(rdsInsightsTopCmd)
+	rdsInsightsTopCmd.Flags().Duration("since", time.Hour, "Length of the window to report on, ending now")
+	rdsInsightsTopCmd.Flags().Int("limit", 10, "Number of statements and users to show (at most 25)")
+	rdsInsightsTopCmd.Flags().Bool("full", false, "Print statements in full")
+}
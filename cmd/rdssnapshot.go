@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var rdsSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create, list, prune, copy and restore RDS snapshots",
+}
+
+var rdsSnapshotCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Take a manual snapshot of an RDS instance or Aurora cluster",
+	Long: `Interactively select your RDS instance or Aurora cluster and take a manual snapshot named
+<source>-<label>-<yyyymmdd-hhmmss> (UTC), then wait until it is available.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		label, _ := cmd.Flags().GetString("label")
+		noWait, _ := cmd.Flags().GetBool("no-wait")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteRDSSnapshotCreate(label, noWait, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var rdsSnapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the manual snapshots of an RDS instance or Aurora cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := functions.ExecuteRDSSnapshotList(); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var rdsSnapshotPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old manual snapshots of an RDS instance or Aurora cluster",
+	Long: `Interactively select your RDS instance or Aurora cluster and delete its manual snapshots beyond the
+newest --keep, or older than --older-than. The snapshots to delete are listed for confirmation first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keep, _ := cmd.Flags().GetInt("keep")
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		if err := functions.ExecuteRDSSnapshotPrune(keep, olderThan, autoApprove); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var rdsSnapshotCopyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy a snapshot to another region or account",
+	Long: `Interactively select a manual snapshot and copy it to --to-region and/or the account of --to-profile.
+For another account, the snapshot is shared with it first and copied using --to-profile.
+
+Encrypted snapshots are re-encrypted with --kms-key, which must be a key in the destination region and
+account. Snapshots encrypted with the default aws/rds key cannot be shared with other accounts.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		toRegion, _ := cmd.Flags().GetString("to-region")
+		toProfile, _ := cmd.Flags().GetString("to-profile")
+		kmsKey, _ := cmd.Flags().GetString("kms-key")
+		noWait, _ := cmd.Flags().GetBool("no-wait")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteRDSSnapshotCopy(toRegion, toProfile, kmsKey, noWait, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var rdsSnapshotRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a snapshot to a new RDS instance or Aurora cluster",
+	Long: `Interactively select a manual snapshot and restore it to a new instance, using the source's subnet
+group, security groups and instance class. Aurora snapshots are restored to a new cluster with one
+writer instance.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		noWait, _ := cmd.Flags().GetBool("no-wait")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if err := functions.ExecuteRDSSnapshotRestore(name, noWait, timeout); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rdsCmd.AddCommand(rdsSnapshotCmd)
+	rdsSnapshotCmd.AddCommand(rdsSnapshotCreateCmd)
+	rdsSnapshotCmd.AddCommand(rdsSnapshotListCmd)
+	rdsSnapshotCmd.AddCommand(rdsSnapshotPruneCmd)
+	rdsSnapshotCmd.AddCommand(rdsSnapshotCopyCmd)
+	rdsSnapshotCmd.AddCommand(rdsSnapshotRestoreCmd)
+
+	rdsSnapshotCreateCmd.Flags().String("label", "infra", "Label in the snapshot name, e.g. pre-migration")
+	rdsSnapshotCreateCmd.Flags().Bool("no-wait", false, "Return once the snapshot has started")
+	rdsSnapshotCreateCmd.Flags().Duration("timeout", time.Hour, "How long to wait for the snapshot")
+
+	rdsSnapshotPruneCmd.Flags().Int("keep", -1, "Number of newest snapshots to keep")
+	rdsSnapshotPruneCmd.Flags().Duration("older-than", 0, "Delete snapshots older than this, e.g. 720h")
+	rdsSnapshotPruneCmd.Flags().BoolP("auto-approve", "a", false, "Delete without confirmation")
+
+	rdsSnapshotCopyCmd.Flags().String("to-region", "", "Region to copy the snapshot to (default the current region)")
+	rdsSnapshotCopyCmd.Flags().String("to-profile", "", "Profile of the account to copy the snapshot to (default the current profile)")
+	rdsSnapshotCopyCmd.Flags().String("kms-key", "", "KMS key ID, ARN or alias in the destination to encrypt the copy with")
+	rdsSnapshotCopyCmd.Flags().Bool("no-wait", false, "Return once the copy has started")
+	rdsSnapshotCopyCmd.Flags().Duration("timeout", 2*time.Hour, "How long to wait for the copy")
+
+	rdsSnapshotRestoreCmd.Flags().String("name", "", "Identifier of the new instance or cluster (prompted for if not set)")
+	rdsSnapshotRestoreCmd.Flags().Bool("no-wait", false, "Return once the restore has started")
+	rdsSnapshotRestoreCmd.Flags().Duration("timeout", time.Hour, "How long to wait for the restored instance or cluster")
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"raid/infra/internal/functions"
+
+	"github.com/spf13/cobra"
+)
+
+var rdsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report versions, maintenance and certificate expiry of every RDS instance and cluster",
+	Long: `Report every RDS instance and Aurora cluster in the selected region with its engine version and the
+newest minor version it can be upgraded to, pending maintenance actions, maintenance and backup windows,
+backup retention, deletion protection, Multi-AZ, and its certificate authority with the days left until
+its server certificate expires.
+
+The report is printed as a table, or as JSON or CSV with --output. Use --file to write it to a file
+instead of stdout, which also carries the interactive prompts.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		file, _ := cmd.Flags().GetString("file")
+		if err := functions.ExecuteRDSStatus(output, file); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rdsCmd.AddCommand(rdsStatusCmd)
+	rdsStatusCmd.Flags().StringP("output", "o", "table", "Output format: table, json or csv")
+	rdsStatusCmd.Flags().String("file", "", "Write the report to this file instead of stdout")
+}
@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/utils"
+)
+
+// Session kinds recorded in the audit log
+const (
+	KindECSExec             = "ecs-exec"
+	KindECSHostExec         = "ecs-host-exec"
+	KindECSPortForward      = "ecs-portforward"
+	KindECSContainerForward = "ecs-container-portforward"
+	KindEC2PortForward      = "ec2-portforward"
+	KindEC2EICEPortForward  = "ec2-eice-portforward"
+	KindDBDump              = "db-dump"
+	KindDBRestore           = "db-restore"
+)
+
+// Session record statuses. A session is written once when it starts and again when it ends,
+// so one killed before it could end still leaves its start record behind.
+const (
+	StatusStarted = "started"
+	StatusEnded   = "ended"
+)
+
+// Session is one audited shell or tunnel session, stored as a line of the JSONL audit log
+type Session struct {
+	ID         string    `json:"id,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Kind       string    `json:"kind"`
+	CallerARN  string    `json:"callerArn"`
+	Profile    string    `json:"profile"`
+	Region     string    `json:"region"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Task       string    `json:"task,omitempty"`
+	Container  string    `json:"container,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Instance   string    `json:"instance,omitempty"`
+	DBEndpoint string    `json:"dbEndpoint,omitempty"`
+	RemotePort int       `json:"remotePort,omitempty"`
+	LocalPort  int       `json:"localPort,omitempty"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	ExitStatus int       `json:"exitStatus"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Returns the audit log path, honouring INFRA_AUDIT_LOG when set
+func LogPath() (string, error) {
+	if path := os.Getenv("INFRA_AUDIT_LOG"); path != "" {
+		return path, nil
+	}
+	dir, err := utils.InfraDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.jsonl"), nil
+}
+
+// Start resolves the caller's identity, stamps the session start time and appends a start record
+func Start(s Session) *Session {
+	s.CallerARN = "unknown"
+	if cfg, err := aws.LoadAWSConfig(s.Profile, s.Region); err == nil {
+		if arn, err := aws.GetCallerARN(cfg); err == nil {
+			s.CallerARN = arn
+		}
+	}
+	s.ID = newSessionID()
+	s.Status = StatusStarted
+	s.StartTime = time.Now().UTC()
+	s.record()
+	return &s
+}
+
+// Returns a random session ID that ties a session's end record to its start record
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Run runs cmd as the session and records it
+func (s *Session) Run(cmd *exec.Cmd) error {
+	err := s.Exec(cmd)
+	s.End(err)
+	return err
+}
+
+// Exec runs cmd without recording it. Ctrl+C is left to the child process
+// so that infra stays alive long enough to record the end of the session.
+func (s *Session) Exec(cmd *exec.Cmd) error {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	return cmd.Run()
+}
+
+// End stamps the session end time and appends an end record, taking the exit status from err
+func (s *Session) End(err error) {
+	status := 0
+	if err != nil {
+		status = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = exitErr.ExitCode()
+		}
+	}
+	s.EndWithStatus(status, err)
+}
+
+// EndWithStatus stamps the session end time and an explicit exit status and appends an end record
+func (s *Session) EndWithStatus(status int, err error) {
+	s.Status = StatusEnded
+	s.EndTime = time.Now().UTC()
+	s.ExitStatus = status
+	s.Error = ""
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.record()
+}
+
+// Appends the session to the audit log, warning on failure rather than failing the session
+func (s *Session) record() {
+	if err := appendSession(s); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+func appendSession(s *Session) error {
+	path, err := LogPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load reads every session recorded in the audit log, in start order. End records replace the start
+// record of their session; sessions that never ended keep their start record and StatusStarted.
+func Load() ([]Session, error) {
+	path, err := LogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Session{}, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	defer f.Close()
+
+	var sessions []Session
+	started := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log entry: %v", err)
+		}
+		if i, ok := started[s.ID]; ok {
+			sessions[i] = s
+			continue
+		}
+		if s.Status == StatusStarted {
+			started[s.ID] = len(sessions)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// ECSScalableTarget is the Application Auto Scaling target of an ECS service's desired count.
+type ECSScalableTarget struct {
+	MinCapacity                int32 `json:"minCapacity"`
+	MaxCapacity                int32 `json:"maxCapacity"`
+	DynamicScalingInSuspended  bool  `json:"dynamicScalingInSuspended"`
+	DynamicScalingOutSuspended bool  `json:"dynamicScalingOutSuspended"`
+	ScheduledScalingSuspended  bool  `json:"scheduledScalingSuspended"`
+}
+
+// Returns the resource ID Application Auto Scaling uses for an ECS service
+func ecsServiceResourceID(cluster, service string) string {
+	return fmt.Sprintf("service/%s/%s", cluster, service)
+}
+
+// GetECSScalableTarget returns the scalable target registered for an ECS service, or nil if it has none.
+func GetECSScalableTarget(profile, region, cluster, service string) (*ECSScalableTarget, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := applicationautoscaling.NewFromConfig(cfg).DescribeScalableTargets(context.TODO(), &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace:  types.ServiceNamespaceEcs,
+		ResourceIds:       []string{ecsServiceResourceID(cluster, service)},
+		ScalableDimension: types.ScalableDimensionECSServiceDesiredCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scalable targets: %w", err)
+	}
+	if len(output.ScalableTargets) == 0 {
+		return nil, nil
+	}
+
+	t := output.ScalableTargets[0]
+	target := &ECSScalableTarget{
+		MinCapacity: aws.ToInt32(t.MinCapacity),
+		MaxCapacity: aws.ToInt32(t.MaxCapacity),
+	}
+	if t.SuspendedState != nil {
+		target.DynamicScalingInSuspended = aws.ToBool(t.SuspendedState.DynamicScalingInSuspended)
+		target.DynamicScalingOutSuspended = aws.ToBool(t.SuspendedState.DynamicScalingOutSuspended)
+		target.ScheduledScalingSuspended = aws.ToBool(t.SuspendedState.ScheduledScalingSuspended)
+	}
+	return target, nil
+}
+
+// PutECSScalableTarget updates the capacity limits and suspended state of an ECS service's scalable target.
+func PutECSScalableTarget(profile, region, cluster, service string, target ECSScalableTarget) error {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return err
+	}
+	_, err = applicationautoscaling.NewFromConfig(cfg).RegisterScalableTarget(context.TODO(), &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  types.ServiceNamespaceEcs,
+		ResourceId:        aws.String(ecsServiceResourceID(cluster, service)),
+		ScalableDimension: types.ScalableDimensionECSServiceDesiredCount,
+		MinCapacity:       aws.Int32(target.MinCapacity),
+		MaxCapacity:       aws.Int32(target.MaxCapacity),
+		SuspendedState: &types.SuspendedState{
+			DynamicScalingInSuspended:  aws.Bool(target.DynamicScalingInSuspended),
+			DynamicScalingOutSuspended: aws.Bool(target.DynamicScalingOutSuspended),
+			ScheduledScalingSuspended:  aws.Bool(target.ScheduledScalingSuspended),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update scalable target: %w", err)
+	}
+	return nil
+}
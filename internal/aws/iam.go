@@ -51,6 +51,14 @@ var (
 		"ecr:UploadLayerPart",
 		"ecr:CompleteLayerUpload",
 	}
+
+	// Actions an ECS task role needs for the ECS exec agent to open its SSM channels
+	SSMMessagesActions = []string{
+		"ssmmessages:CreateControlChannel",
+		"ssmmessages:CreateDataChannel",
+		"ssmmessages:OpenControlChannel",
+		"ssmmessages:OpenDataChannel",
+	}
 )
 
 // LoadAWSConfig loads the AWS configuration for the given profile and region.
@@ -75,6 +83,16 @@ func GetAWSAccountID(cfg aws.Config) (string, error) {
 	return *callerIdentity.Account, nil
 }
 
+// GetCallerARN retrieves the ARN of the identity behind the current profile.
+func GetCallerARN(cfg aws.Config) (string, error) {
+	stsClient := sts.NewFromConfig(cfg)
+	callerIdentity, err := stsClient.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return aws.ToString(callerIdentity.Arn), nil
+}
+
 // CreateTrustPolicy generates a trust policy JSON for the given AWS account ID.
 func CreateTrustPolicy(accountID string) (string, error) {
 	trustPolicy := map[string]interface{}{
@@ -327,3 +345,69 @@ func CreateOIDCProvider(profile, region, gitURL string) error {
 	fmt.Println("OIDC Provider created successfully")
 	return nil
 }
+
+// SimulatePrincipalActions simulates the principal's IAM policies for the given actions and
+// returns the actions that are not allowed. resourceARNs may be empty to simulate against "*".
+func SimulatePrincipalActions(profile, region, principalARN string, actions, resourceARNs []string) ([]string, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	iamClient := iam.NewFromConfig(cfg)
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalARN),
+		ActionNames:     actions,
+		ResourceArns:    resourceARNs,
+	}
+
+	var denied []string
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(iamClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate IAM policy for %s: %w", principalARN, err)
+		}
+		for _, result := range page.EvaluationResults {
+			if result.EvalDecision != iamtypes.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, aws.ToString(result.EvalActionName))
+			}
+		}
+	}
+	return denied, nil
+}
+
+// AttachSSMMessagesPolicy attaches an inline policy allowing the ECS exec ssmmessages actions to the specified role.
+func AttachSSMMessagesPolicy(profile, region, roleName string) error {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return err
+	}
+
+	inlinePolicy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   SSMMessagesActions,
+				"Resource": "*",
+			},
+		},
+	}
+
+	inlinePolicyJSON, err := json.Marshal(inlinePolicy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ssmmessages inline policy: %w", err)
+	}
+
+	iamClient := iam.NewFromConfig(cfg)
+	_, err = iamClient.PutRolePolicy(context.TODO(), &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String("ECSExecSSMMessages"),
+		PolicyDocument: aws.String(string(inlinePolicyJSON)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach ssmmessages inline policy to role: %w", err)
+	}
+	return nil
+}
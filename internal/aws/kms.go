@@ -0,0 +1,24 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// IsAWSManagedKey reports whether a KMS key, given by ID, ARN or alias, is managed by AWS,
+// like the default aws/rds key. Resources encrypted with one cannot be shared with other accounts.
+func IsAWSManagedKey(profile, region, keyID string) (bool, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return false, err
+	}
+	output, err := kms.NewFromConfig(cfg).DescribeKey(context.TODO(), &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return false, fmt.Errorf("failed to describe KMS key %s: %w", keyID, err)
+	}
+	return output.KeyMetadata.KeyManager == kmstypes.KeyManagerTypeAws, nil
+}
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// LogEvent is a CloudWatch Logs event from a single log stream.
+type LogEvent struct {
+	EventID    string
+	StreamName string
+	Timestamp  time.Time
+	Message    string
+}
+
+// LogTailer fetches new events from a set of log streams on each poll.
+type LogTailer struct {
+	client  *cloudwatchlogs.Client
+	group   string
+	streams []string
+	pattern string
+	next    time.Time
+	seen    map[string]time.Time
+}
+
+// NewLogTailer returns a LogTailer for the given streams in a log group, starting at since.
+// pattern is a CloudWatch Logs filter pattern and may be empty.
+func NewLogTailer(profile, region, group string, streams []string, pattern string, since time.Time) (*LogTailer, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	return &LogTailer{
+		client:  cloudwatchlogs.NewFromConfig(cfg),
+		group:   group,
+		streams: streams,
+		pattern: pattern,
+		next:    since,
+		seen:    map[string]time.Time{},
+	}, nil
+}
+
+// maxFilterLogStreams is the most log stream names FilterLogEvents accepts in one request.
+const maxFilterLogStreams = 100
+
+// Poll returns the events written since the previous poll, oldest first.
+func (t *LogTailer) Poll() ([]LogEvent, error) {
+	var events []LogEvent
+	for start := 0; start < len(t.streams); start += maxFilterLogStreams {
+		end := start + maxFilterLogStreams
+		if end > len(t.streams) {
+			end = len(t.streams)
+		}
+		batch, err := t.poll(t.streams[start:end])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, batch...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	// Re-read from the newest timestamp next time, since more events may share it,
+	// and forget events that can no longer be returned again.
+	if len(events) > 0 {
+		t.next = events[len(events)-1].Timestamp
+		for id, ts := range t.seen {
+			if ts.Before(t.next) {
+				delete(t.seen, id)
+			}
+		}
+	}
+	return events, nil
+}
+
+// poll returns the unseen events in the given streams since the previous poll.
+func (t *LogTailer) poll(streams []string) ([]LogEvent, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   aws.String(t.group),
+		LogStreamNames: streams,
+		StartTime:      aws.Int64(t.next.UnixMilli()),
+	}
+	if t.pattern != "" {
+		input.FilterPattern = aws.String(t.pattern)
+	}
+
+	var events []LogEvent
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(t.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch log events from %s: %w", t.group, err)
+		}
+		for _, e := range page.Events {
+			id := aws.ToString(e.EventId)
+			if _, ok := t.seen[id]; ok {
+				continue
+			}
+			ts := time.UnixMilli(aws.ToInt64(e.Timestamp))
+			t.seen[id] = ts
+			events = append(events, LogEvent{
+				EventID:    id,
+				StreamName: aws.ToString(e.LogStreamName),
+				Timestamp:  ts,
+				Message:    aws.ToString(e.Message),
+			})
+		}
+	}
+	return events, nil
+}
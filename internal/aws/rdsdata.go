@@ -0,0 +1,216 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata/types"
+)
+
+// DataAPI runs SQL against an Aurora cluster through the RDS Data API, authenticating with a Secrets Manager secret
+type DataAPI struct {
+	client     *rdsdata.Client
+	clusterArn string
+	secretArn  string
+	database   string
+}
+
+// DataAPIResult is the result of a statement run through the Data API. Columns is empty for
+// statements that return no result set.
+type DataAPIResult struct {
+	Columns        []string
+	Rows           [][]interface{}
+	RecordsUpdated int64
+}
+
+// NewDataAPI returns a DataAPI for a cluster, using database or the cluster's default if empty.
+func NewDataAPI(profile, region, clusterArn, secretArn, database string) (*DataAPI, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	return &DataAPI{client: rdsdata.NewFromConfig(cfg), clusterArn: clusterArn, secretArn: secretArn, database: database}, nil
+}
+
+func (d *DataAPI) databaseParam() *string {
+	if d.database == "" {
+		return nil
+	}
+	return aws.String(d.database)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// BeginTransaction starts a transaction and returns its ID. It is rolled back automatically
+// if it is neither committed nor rolled back within three minutes.
+func (d *DataAPI) BeginTransaction() (string, error) {
+	output, err := d.client.BeginTransaction(context.TODO(), &rdsdata.BeginTransactionInput{
+		ResourceArn: aws.String(d.clusterArn),
+		SecretArn:   aws.String(d.secretArn),
+		Database:    d.databaseParam(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return aws.ToString(output.TransactionId), nil
+}
+
+// CommitTransaction commits a transaction.
+func (d *DataAPI) CommitTransaction(transactionID string) error {
+	_, err := d.client.CommitTransaction(context.TODO(), &rdsdata.CommitTransactionInput{
+		ResourceArn:   aws.String(d.clusterArn),
+		SecretArn:     aws.String(d.secretArn),
+		TransactionId: aws.String(transactionID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RollbackTransaction rolls back a transaction.
+func (d *DataAPI) RollbackTransaction(transactionID string) error {
+	_, err := d.client.RollbackTransaction(context.TODO(), &rdsdata.RollbackTransactionInput{
+		ResourceArn:   aws.String(d.clusterArn),
+		SecretArn:     aws.String(d.secretArn),
+		TransactionId: aws.String(transactionID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	return nil
+}
+
+// Execute runs a statement, in the given transaction if transactionID is set.
+func (d *DataAPI) Execute(sql, transactionID string) (*DataAPIResult, error) {
+	output, err := d.client.ExecuteStatement(context.TODO(), &rdsdata.ExecuteStatementInput{
+		ResourceArn:           aws.String(d.clusterArn),
+		SecretArn:             aws.String(d.secretArn),
+		Database:              d.databaseParam(),
+		Sql:                   aws.String(sql),
+		TransactionId:         optionalString(transactionID),
+		IncludeResultMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	result := &DataAPIResult{RecordsUpdated: output.NumberOfRecordsUpdated}
+	for _, column := range output.ColumnMetadata {
+		name := aws.ToString(column.Label)
+		if name == "" {
+			name = aws.ToString(column.Name)
+		}
+		result.Columns = append(result.Columns, name)
+	}
+	for _, record := range output.Records {
+		row := make([]interface{}, len(record))
+		for i, field := range record {
+			row[i] = fieldValue(field)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// BatchExecute runs a statement once for each set of named parameters (referenced as :name in
+// the statement), in the given transaction if transactionID is set, and returns how many ran.
+func (d *DataAPI) BatchExecute(sql string, parameterSets []map[string]interface{}, transactionID string) (int, error) {
+	sets := make([][]types.SqlParameter, 0, len(parameterSets))
+	for i, set := range parameterSets {
+		var params []types.SqlParameter
+		for name, value := range set {
+			field, err := sqlField(value)
+			if err != nil {
+				return 0, fmt.Errorf("parameter set %d, %s: %w", i+1, name, err)
+			}
+			params = append(params, types.SqlParameter{Name: aws.String(name), Value: field})
+		}
+		sets = append(sets, params)
+	}
+
+	output, err := d.client.BatchExecuteStatement(context.TODO(), &rdsdata.BatchExecuteStatementInput{
+		ResourceArn:   aws.String(d.clusterArn),
+		SecretArn:     aws.String(d.secretArn),
+		Database:      d.databaseParam(),
+		Sql:           aws.String(sql),
+		ParameterSets: sets,
+		TransactionId: optionalString(transactionID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute batch statement: %w", err)
+	}
+	return len(output.UpdateResults), nil
+}
+
+// Converts a Data API field to a Go value; blobs are base64 encoded and arrays become slices
+func fieldValue(field types.Field) interface{} {
+	switch v := field.(type) {
+	case *types.FieldMemberIsNull:
+		return nil
+	case *types.FieldMemberStringValue:
+		return v.Value
+	case *types.FieldMemberLongValue:
+		return v.Value
+	case *types.FieldMemberDoubleValue:
+		return v.Value
+	case *types.FieldMemberBooleanValue:
+		return v.Value
+	case *types.FieldMemberBlobValue:
+		return base64.StdEncoding.EncodeToString(v.Value)
+	case *types.FieldMemberArrayValue:
+		return arrayValue(v.Value)
+	}
+	return nil
+}
+
+func arrayValue(array types.ArrayValue) interface{} {
+	switch v := array.(type) {
+	case *types.ArrayValueMemberStringValues:
+		return v.Value
+	case *types.ArrayValueMemberLongValues:
+		return v.Value
+	case *types.ArrayValueMemberDoubleValues:
+		return v.Value
+	case *types.ArrayValueMemberBooleanValues:
+		return v.Value
+	case *types.ArrayValueMemberArrayValues:
+		values := make([]interface{}, len(v.Value))
+		for i, nested := range v.Value {
+			values[i] = arrayValue(nested)
+		}
+		return values
+	}
+	return nil
+}
+
+// Converts a value decoded from JSON (with UseNumber) to a Data API parameter field
+func sqlField(value interface{}) (types.Field, error) {
+	switch v := value.(type) {
+	case nil:
+		return &types.FieldMemberIsNull{Value: true}, nil
+	case string:
+		return &types.FieldMemberStringValue{Value: v}, nil
+	case bool:
+		return &types.FieldMemberBooleanValue{Value: v}, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return &types.FieldMemberLongValue{Value: n}, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &types.FieldMemberDoubleValue{Value: f}, nil
+	}
+	return nil, fmt.Errorf("unsupported parameter value %v (use a string, number, boolean or null)", value)
+}
@@ -6,9 +6,13 @@ import (
 	"bytes"
 	"fmt"
 	"encoding/json"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
@@ -146,3 +150,110 @@ func applyBucketPolicy(s3Client *s3.Client, bucketName string) error {
 	fmt.Println("Bucket policy to deny non-SSL access successfully applied.")
 	return nil
 }
+
+// UploadObject uploads body to the given bucket and key using the specified profile and region.
+func UploadObject(profile, region, bucketName, key string, body []byte) error {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return err
+	}
+
+	s3Client := s3.NewFromConfig(cfg)
+	_, err = s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", bucketName, key, err)
+	}
+	return nil
+}
+
+// S3Object is an object found by ListObjects
+type S3Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ParseS3URI splits an s3://bucket/key URI into its bucket and key (or prefix).
+func ParseS3URI(uri string) (string, string, error) {
+	if !strings.HasPrefix(uri, "s3://") {
+		return "", "", fmt.Errorf("%s is not an s3:// URI", uri)
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(uri, "s3://"), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%s has no bucket", uri)
+	}
+	return bucket, key, nil
+}
+
+// UploadStream uploads body to the given bucket and key in parts as it is read, so its size need not
+// be known, encrypting it with SSE-KMS under kmsKeyID (the AWS managed aws/s3 key if empty).
+func UploadStream(profile, region, bucketName, key, kmsKeyID string, body io.Reader) error {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(bucketName),
+		Key:                  aws.String(key),
+		Body:                 body,
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	}
+	if kmsKeyID != "" {
+		input.SSEKMSKeyId = aws.String(kmsKeyID)
+	}
+	uploader := manager.NewUploader(s3.NewFromConfig(cfg))
+	if _, err := uploader.Upload(context.TODO(), input); err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", bucketName, key, err)
+	}
+	return nil
+}
+
+// OpenObject returns the body of an object to stream from, and its size. The caller must close the body.
+func OpenObject(profile, region, bucketName, key string) (io.ReadCloser, int64, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	output, err := s3.NewFromConfig(cfg).GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download s3://%s/%s: %w", bucketName, key, err)
+	}
+	return output.Body, aws.ToInt64(output.ContentLength), nil
+}
+
+// ListObjects lists the objects in a bucket under prefix.
+func ListObjects(profile, region, bucketName, prefix string) ([]S3Object, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []S3Object
+	paginator := s3.NewListObjectsV2Paginator(s3.NewFromConfig(cfg), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", bucketName, prefix, err)
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, S3Object{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ResolveSecretReference fetches the value an ECS secret's valueFrom points at. valueFrom is either an
+// SSM parameter name or ARN, or a Secrets Manager secret ARN optionally followed by
+// :json-key:version-stage:version-id as in ECS task definitions.
+func ResolveSecretReference(profile, region, valueFrom string) (string, error) {
+	parts := strings.Split(valueFrom, ":")
+	if len(parts) >= 7 && parts[0] == "arn" && parts[2] == "secretsmanager" {
+		return resolveSecretsManagerReference(profile, parts)
+	}
+	if len(parts) >= 6 && parts[0] == "arn" {
+		region = parts[3]
+	}
+
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := ssm.NewFromConfig(cfg).GetParameter(context.TODO(), &ssm.GetParameterInput{
+		Name:           aws.String(valueFrom),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get SSM parameter %s: %w", valueFrom, err)
+	}
+	return aws.ToString(output.Parameter.Value), nil
+}
+
+// Fetches a Secrets Manager secret from the split parts of its ECS valueFrom ARN
+func resolveSecretsManagerReference(profile string, parts []string) (string, error) {
+	secretID := strings.Join(parts[:7], ":")
+	field := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	jsonKey, versionStage, versionID := field(7), field(8), field(9)
+
+	cfg, err := LoadAWSConfig(profile, parts[3])
+	if err != nil {
+		return "", err
+	}
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)}
+	if versionStage != "" {
+		input.VersionStage = aws.String(versionStage)
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	output, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(context.TODO(), input)
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", secretID, err)
+	}
+
+	value := aws.ToString(output.SecretString)
+	if jsonKey == "" {
+		return value, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not JSON, so key %s cannot be read: %w", secretID, jsonKey, err)
+	}
+	v, ok := fields[jsonKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", secretID, jsonKey)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// GetDatabaseCredentials fetches the username and password from a Secrets Manager secret in the
+// JSON format RDS and its rotation functions use
+func GetDatabaseCredentials(profile, region, secretID string) (string, string, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return "", "", err
+	}
+	output, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get secret %s: %w", secretID, err)
+	}
+
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal([]byte(aws.ToString(output.SecretString)), &credentials); err != nil {
+		return "", "", fmt.Errorf("secret %s is not a JSON database secret: %w", secretID, err)
+	}
+	if credentials.Password == "" {
+		return "", "", fmt.Errorf("secret %s has no password", secretID)
+	}
+	return credentials.Username, credentials.Password, nil
+}
+
+// SecretSummary is a Secrets Manager secret found by ListSecrets
+type SecretSummary struct {
+	Name        string
+	ARN         string
+	Description string
+}
+
+// ListSecrets lists the Secrets Manager secrets in the region.
+func ListSecrets(profile, region string) ([]SecretSummary, error) {
+	cfg, err := LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []SecretSummary
+	paginator := secretsmanager.NewListSecretsPaginator(secretsmanager.NewFromConfig(cfg), &secretsmanager.ListSecretsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, secret := range page.SecretList {
+			secrets = append(secrets, SecretSummary{
+				Name:        aws.ToString(secret.Name),
+				ARN:         aws.ToString(secret.ARN),
+				Description: aws.ToString(secret.Description),
+			})
+		}
+	}
+	return secrets, nil
+}
@@ -6,47 +6,62 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ec2sdk "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"raid/infra/internal/audit"
+	"raid/infra/internal/aws"
 	"raid/infra/internal/utils"
 )
 
-// Retrieves a list of EC2 instances with their instance IDs and names.
-func FetchEC2Instances(profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ec2", "describe-instances",
-		"--query", "Reservations[].Instances[].[InstanceId, Tags[?Key=='Name'].Value | [0], State.Name]",
-		"--output", "text",
-		"--profile", profile,
-		"--region", region)
-
-	output, err := cmd.Output()
+// Returns an EC2 client for the given profile and region
+func newClient(profile, region string) (*ec2sdk.Client, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch EC2 instances: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
-		return nil, fmt.Errorf("failed to fetch EC2 instances: %v", err)
+		return nil, err
 	}
+	return ec2sdk.NewFromConfig(cfg), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("no EC2 instances available")
+// Returns a describe filter matching any of the given values
+func filter(name string, values ...string) ec2types.Filter {
+	return ec2types.Filter{Name: awssdk.String(name), Values: values}
+}
+
+// Retrieves a list of EC2 instances with their instance IDs and names.
+func FetchEC2Instances(profile, region string) ([]string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
 	}
 
 	var instances []string
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 3 {
-			instanceID := fields[0]
-			instanceName := strings.Join(fields[1:len(fields)-1], " ")
-			instanceState := fields[len(fields)-1]
-			instances = append(instances, fmt.Sprintf("%s - %s [%s]", instanceID, instanceName, instanceState))
-		} else if len(fields) == 2 {
-			instanceID := fields[0]
-			instanceState := fields[1]
-			instances = append(instances, fmt.Sprintf("%s - (No Name) [%s]", instanceID, instanceState))
+	paginator := ec2sdk.NewDescribeInstancesPaginator(client, &ec2sdk.DescribeInstancesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch EC2 instances: %v", err)
 		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceName := "(No Name)"
+				for _, tag := range instance.Tags {
+					if awssdk.ToString(tag.Key) == "Name" && awssdk.ToString(tag.Value) != "" {
+						instanceName = awssdk.ToString(tag.Value)
+					}
+				}
+				instanceState := ""
+				if instance.State != nil {
+					instanceState = string(instance.State.Name)
+				}
+				instances = append(instances, fmt.Sprintf("%s - %s [%s]", awssdk.ToString(instance.InstanceId), instanceName, instanceState))
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no EC2 instances available")
 	}
 
 	return instances, nil
@@ -88,7 +103,15 @@ func StartEC2SSMSession(instanceID, profile, dbHost, region string, dbPort int)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
+	session := audit.Start(audit.Session{
+		Kind:       audit.KindEC2PortForward,
+		Profile:    profile,
+		Region:     region,
+		Instance:   instanceID,
+		DBEndpoint: fmt.Sprintf("%s:%d", dbHost, dbPort),
+		LocalPort:  localPort,
+	})
+	if err := session.Run(cmd); err != nil {
 		return fmt.Errorf("failed to start SSM session: %v", err)
 	}
 
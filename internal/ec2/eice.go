@@ -0,0 +1,286 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	ec2sdk "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/gorilla/websocket"
+
+	"raid/infra/internal/audit"
+	"raid/infra/internal/aws"
+	"raid/infra/internal/utils"
+)
+
+// SHA-256 of an empty payload, used when presigning the tunnel URL
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Maximum tunnel duration accepted by EC2 Instance Connect Endpoints, in seconds
+const maxEICETunnelDuration = 3600
+
+// InstanceNetwork holds the network placement of an EC2 instance
+type InstanceNetwork struct {
+	PrivateIPAddress string
+	SubnetID         string
+	VpcID            string
+}
+
+// InstanceConnectEndpoint is an EC2 Instance Connect Endpoint in the instance's VPC
+type InstanceConnectEndpoint struct {
+	ID       string
+	DNSName  string
+	SubnetID string
+	VpcID    string
+	State    string
+}
+
+// Fetches the private IP address, subnet and VPC of an EC2 instance
+func GetEC2InstanceNetwork(instanceID, profile, region string) (*InstanceNetwork, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeInstances(context.TODO(), &ec2sdk.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EC2 instance: %v", err)
+	}
+	if len(output.Reservations) == 0 || len(output.Reservations[0].Instances) == 0 {
+		return nil, fmt.Errorf("EC2 instance %s not found", instanceID)
+	}
+	instance := output.Reservations[0].Instances[0]
+	return &InstanceNetwork{
+		PrivateIPAddress: awssdk.ToString(instance.PrivateIpAddress),
+		SubnetID:         awssdk.ToString(instance.SubnetId),
+		VpcID:            awssdk.ToString(instance.VpcId),
+	}, nil
+}
+
+// Finds the EC2 Instance Connect Endpoint for an instance, preferring one in the instance's subnet
+func FindInstanceConnectEndpoint(network *InstanceNetwork, profile, region string) (*InstanceConnectEndpoint, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []InstanceConnectEndpoint
+	paginator := ec2sdk.NewDescribeInstanceConnectEndpointsPaginator(client, &ec2sdk.DescribeInstanceConnectEndpointsInput{
+		Filters: []ec2types.Filter{filter("vpc-id", network.VpcID), filter("state", "create-complete")},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch EC2 Instance Connect Endpoints: %v", err)
+		}
+		for _, e := range page.InstanceConnectEndpoints {
+			endpoints = append(endpoints, InstanceConnectEndpoint{
+				ID:       awssdk.ToString(e.InstanceConnectEndpointId),
+				DNSName:  awssdk.ToString(e.DnsName),
+				SubnetID: awssdk.ToString(e.SubnetId),
+				VpcID:    awssdk.ToString(e.VpcId),
+				State:    string(e.State),
+			})
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no EC2 Instance Connect Endpoint found in %s", network.VpcID)
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.SubnetID == network.SubnetID {
+			return &endpoint, nil
+		}
+	}
+	return &endpoints[0], nil
+}
+
+// Starts a local port forward through an EC2 Instance Connect Endpoint.
+// When remoteHost is empty the instance itself is the target.
+func StartEC2EICESession(instanceID, profile, remoteHost, region string, remotePort int) error {
+	network, err := GetEC2InstanceNetwork(instanceID, profile, region)
+	if err != nil {
+		return err
+	}
+	endpoint, err := FindInstanceConnectEndpoint(network, profile, region)
+	if err != nil {
+		return err
+	}
+
+	remoteIP := network.PrivateIPAddress
+	if remoteHost != "" {
+		remoteIP, err = resolveIPv4(remoteHost)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return err
+	}
+
+	localPort, err := utils.PromptLocalPortNumber()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen on local port %d: %v", localPort, err)
+	}
+	defer listener.Close()
+
+	fmt.Printf("Using EC2 Instance Connect Endpoint: %s (%s)\n", endpoint.ID, endpoint.SubnetID)
+	fmt.Printf("Forwarding localhost:%d to %s:%d\n", localPort, remoteIP, remotePort)
+	fmt.Println("Waiting for connections. Press Ctrl+C to stop.")
+
+	record := audit.Session{
+		Kind:      audit.KindEC2EICEPortForward,
+		Profile:   profile,
+		Region:    region,
+		Instance:  instanceID,
+		LocalPort: localPort,
+	}
+	if remoteHost != "" {
+		record.DBEndpoint = fmt.Sprintf("%s:%d", remoteHost, remotePort)
+	} else {
+		record.RemotePort = remotePort
+	}
+	session := audit.Start(record)
+
+	// Stop accepting on Ctrl+C so the end of the session is recorded
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	stopped := make(chan struct{})
+	go func() {
+		<-interrupts
+		close(stopped)
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-stopped:
+				fmt.Println("Tunnel stopped.")
+				session.End(nil)
+				return nil
+			default:
+			}
+			err = fmt.Errorf("failed to accept connection: %v", err)
+			session.End(err)
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			signedURL, err := presignEICETunnelURL(context.Background(), cfg, endpoint, remoteIP, remotePort)
+			if err != nil {
+				fmt.Printf("Failed to sign tunnel request: %v\n", err)
+				return
+			}
+			if err := proxyEICETunnel(conn, signedURL); err != nil {
+				fmt.Printf("Tunnel closed with error: %v\n", err)
+			}
+		}()
+	}
+}
+
+// Builds a SigV4 presigned websocket URL for the endpoint's openTunnel API
+func presignEICETunnelURL(ctx context.Context, cfg awssdk.Config, endpoint *InstanceConnectEndpoint, remoteIP string, remotePort int) (string, error) {
+	query := url.Values{}
+	query.Set("instanceConnectEndpointId", endpoint.ID)
+	query.Set("maxTunnelDuration", strconv.Itoa(maxEICETunnelDuration))
+	query.Set("privateIpAddress", remoteIP)
+	query.Set("remotePort", strconv.Itoa(remotePort))
+	query.Set("X-Amz-Expires", "60")
+
+	tunnelURL := url.URL{Scheme: "wss", Host: endpoint.DNSName, Path: "/openTunnel", RawQuery: query.Encode()}
+	req, err := http.NewRequest(http.MethodGet, tunnelURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	signedURL, _, err := v4.NewSigner().PresignHTTP(ctx, creds, req, emptyPayloadHash, "ec2-instance-connect", cfg.Region, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return signedURL, nil
+}
+
+// Copies data between a local connection and a websocket tunnel until either side closes
+func proxyEICETunnel(conn net.Conn, signedURL string) error {
+	ws, resp, err := websocket.DefaultDialer.Dial(signedURL, nil)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("failed to open tunnel: %s", resp.Status)
+		}
+		return fmt.Errorf("failed to open tunnel: %v", err)
+	}
+	defer ws.Close()
+
+	done := make(chan error, 2)
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if werr := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					done <- werr
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				done <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					err = nil
+				}
+				done <- err
+				return
+			}
+			if _, err := conn.Write(data); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+	return <-done
+}
+
+// Resolves a hostname to its first IPv4 address
+func resolveIPv4(host string) (string, error) {
+	addrs, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if ip := addr.To4(); ip != nil {
+			return ip.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no IPv4 address found for %s", host)
+}
@@ -0,0 +1,119 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ec2sdk "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Subnet is the subset of an EC2 subnet description used by infra
+type Subnet struct {
+	SubnetID string
+	VpcID    string
+}
+
+// VPCEndpoint is an endpoint for an AWS service within a VPC
+type VPCEndpoint struct {
+	VpcEndpointID     string
+	ServiceName       string
+	VpcEndpointType   string
+	State             string
+	PrivateDNSEnabled bool
+}
+
+// Fetches an EC2 subnet
+func GetSubnet(subnetID, profile, region string) (*Subnet, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeSubnets(context.TODO(), &ec2sdk.DescribeSubnetsInput{SubnetIds: []string{subnetID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnet: %v", err)
+	}
+	if len(output.Subnets) == 0 {
+		return nil, fmt.Errorf("subnet %s not found", subnetID)
+	}
+	return &Subnet{SubnetID: awssdk.ToString(output.Subnets[0].SubnetId), VpcID: awssdk.ToString(output.Subnets[0].VpcId)}, nil
+}
+
+// Fetches every route table matching the filters
+func describeRouteTables(client *ec2sdk.Client, filters ...ec2types.Filter) ([]ec2types.RouteTable, error) {
+	var tables []ec2types.RouteTable
+	paginator := ec2sdk.NewDescribeRouteTablesPaginator(client, &ec2sdk.DescribeRouteTablesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe route tables: %v", err)
+		}
+		tables = append(tables, page.RouteTables...)
+	}
+	return tables, nil
+}
+
+// Returns the target of the subnet's default route (a NAT gateway, internet gateway,
+// transit gateway or network interface), or "" if the subnet has no route to the internet
+func GetSubnetDefaultRoute(subnet *Subnet, profile, region string) (string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	tables, err := describeRouteTables(client, filter("association.subnet-id", subnet.SubnetID))
+	if err != nil {
+		return "", err
+	}
+
+	// Subnets without an explicit association use the VPC's main route table
+	if len(tables) == 0 {
+		tables, err = describeRouteTables(client, filter("vpc-id", subnet.VpcID), filter("association.main", "true"))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, table := range tables {
+		for _, route := range table.Routes {
+			if awssdk.ToString(route.DestinationCidrBlock) != "0.0.0.0/0" || route.State != ec2types.RouteStateActive {
+				continue
+			}
+			for _, target := range []*string{route.NatGatewayId, route.TransitGatewayId, route.NetworkInterfaceId, route.GatewayId} {
+				if t := awssdk.ToString(target); t != "" && t != "local" && !strings.HasPrefix(t, "vpce-") {
+					return t, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// Fetches the VPC endpoints in a VPC
+func GetVPCEndpoints(vpcID, profile, region string) ([]VPCEndpoint, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []VPCEndpoint
+	paginator := ec2sdk.NewDescribeVpcEndpointsPaginator(client, &ec2sdk.DescribeVpcEndpointsInput{
+		Filters: []ec2types.Filter{filter("vpc-id", vpcID)},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe VPC endpoints: %v", err)
+		}
+		for _, e := range page.VpcEndpoints {
+			endpoints = append(endpoints, VPCEndpoint{
+				VpcEndpointID:     awssdk.ToString(e.VpcEndpointId),
+				ServiceName:       awssdk.ToString(e.ServiceName),
+				VpcEndpointType:   string(e.VpcEndpointType),
+				State:             string(e.State),
+				PrivateDNSEnabled: awssdk.ToBool(e.PrivateDnsEnabled),
+			})
+		}
+	}
+	return endpoints, nil
+}
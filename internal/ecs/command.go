@@ -0,0 +1,171 @@
+package ecs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"raid/infra/internal/audit"
+)
+
+// RunECSCommand runs a one-shot command in a container, streaming its output line by line
+// to out, and returns the remote command's exit status. The remote side always runs under
+// a pseudo-terminal, so the command's stdout and stderr arrive merged on out.
+func RunECSCommand(profile, cluster, taskID, containerName, region, command string, out io.Writer) (int, error) {
+	return RunECSCommandWithInput(profile, cluster, taskID, containerName, region, command, nil, out)
+}
+
+// RunECSCommandWithInput is RunECSCommand with input fed to the remote command's stdin. The input
+// is only sent once the remote shell has turned terminal echo off and printed a ready marker, so
+// none of it is echoed back into the output.
+func RunECSCommandWithInput(profile, cluster, taskID, containerName, region, command string, input io.Reader, out io.Writer) (int, error) {
+	id := time.Now().UnixNano()
+	readyMarker := fmt.Sprintf("__INFRA_READY_%d__", id)
+	exitMarker := fmt.Sprintf("__INFRA_EXIT_%d__", id)
+	wrapped := fmt.Sprintf("sh -c %s", shellQuote(fmt.Sprintf("stty -echo 2>/dev/null; echo %s; %s; echo %s:$?", readyMarker, command, exitMarker)))
+
+	cmd := exec.Command("aws", "ecs", "execute-command",
+		"--cluster", cluster,
+		"--task", taskID,
+		"--container", containerName,
+		"--interactive",
+		"--command", wrapped,
+		"--profile", profile,
+		"--region", region)
+
+	filter := &sessionOutputFilter{
+		out:         out,
+		readyMarker: readyMarker,
+		exitMarker:  exitMarker,
+		exitStatus:  -1,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	cmd.Stdout = filter
+	cmd.Stderr = os.Stderr
+
+	// session-manager-plugin ends the session as soon as its stdin reaches EOF, which would cut
+	// the command short, so stdin is held open until the command reports its exit status
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return -1, fmt.Errorf("failed to open session input: %v", err)
+	}
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		defer stdin.Close()
+		if input != nil {
+			select {
+			case <-filter.ready:
+			case <-exited:
+				return
+			}
+			if _, err := io.Copy(stdin, input); err != nil {
+				return
+			}
+		}
+		select {
+		case <-filter.done:
+		case <-exited:
+		}
+	}()
+
+	session := audit.Start(audit.Session{
+		Kind:      audit.KindECSExec,
+		Profile:   profile,
+		Region:    region,
+		Cluster:   cluster,
+		Task:      taskID,
+		Container: containerName,
+		Command:   command,
+	})
+	err = session.Exec(cmd)
+	filter.flush()
+
+	if err == nil && !filter.finished {
+		err = fmt.Errorf("session ended before the command reported an exit status")
+	}
+	session.EndWithStatus(filter.exitStatus, err)
+	if err != nil {
+		return -1, fmt.Errorf("failed to run command in ECS container: %v", err)
+	}
+	return filter.exitStatus, nil
+}
+
+// sessionOutputFilter drops the session-manager-plugin banners before the ready marker and
+// after the exit marker of a one-shot session, and forwards the command's output lines between them
+type sessionOutputFilter struct {
+	out         io.Writer
+	readyMarker string
+	exitMarker  string
+	buf         []byte
+	started     bool
+	finished    bool
+	ready       chan struct{}
+	done        chan struct{}
+	exitStatus  int
+}
+
+func (f *sessionOutputFilter) Write(p []byte) (int, error) {
+	f.buf = append(f.buf, p...)
+	for {
+		i := bytes.IndexByte(f.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(f.buf[:i]), "\r")
+		f.buf = f.buf[i+1:]
+		if err := f.handleLine(line, true); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (f *sessionOutputFilter) flush() {
+	if len(f.buf) > 0 {
+		f.handleLine(strings.TrimRight(string(f.buf), "\r"), false)
+		f.buf = nil
+	}
+}
+
+func (f *sessionOutputFilter) handleLine(line string, newline bool) error {
+	if f.finished {
+		return nil
+	}
+	if !f.started {
+		if strings.TrimSpace(line) == f.readyMarker {
+			f.started = true
+			close(f.ready)
+		}
+		return nil
+	}
+	if idx := strings.Index(line, f.exitMarker+":"); idx >= 0 {
+		f.finished = true
+		close(f.done)
+		if status, err := strconv.Atoi(strings.TrimSpace(line[idx+len(f.exitMarker)+1:])); err == nil {
+			f.exitStatus = status
+		}
+		// Output that did not end in a newline shares its line with the marker
+		if idx > 0 {
+			_, err := io.WriteString(f.out, line[:idx]+"\n")
+			return err
+		}
+		return nil
+	}
+	if newline {
+		line += "\n"
+	}
+	_, err := io.WriteString(f.out, line)
+	return err
+}
+
+// Quotes s for use as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,450 @@
+package ecs
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"raid/infra/internal/utils"
+)
+
+// Bytes per base64 line, matching the 76-column output of coreutils and busybox base64. Uploads
+// pass through the remote terminal in canonical mode, so lines must stay well under its 4095-byte limit.
+const base64LineBytes = 57
+
+// Returned by statContainerFile when the path is a directory
+var errRemoteDirectory = errors.New("path is a directory")
+
+// Copies a file or directory out of a container over ECS exec, verifying its SHA-256 checksum.
+// Directories are archived with tar in the container and unpacked into localPath.
+func CopyFromContainer(profile, cluster, taskID, containerName, region, remotePath, localPath string) error {
+	size, remoteSum, err := statContainerFile(profile, cluster, taskID, containerName, region, remotePath)
+	if errors.Is(err, errRemoteDirectory) {
+		return copyDirFromContainer(profile, cluster, taskID, containerName, region, remotePath, localPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := downloadFile(profile, cluster, taskID, containerName, region, remotePath, localPath, filepath.Base(remotePath), size, remoteSum, false); err != nil {
+		return err
+	}
+	fmt.Printf("Copied %s (%s, sha256 %s)\n", localPath, utils.FormatBytes(size), remoteSum)
+	return nil
+}
+
+// Archives a directory with tar in the container, downloads the archive and unpacks it into localPath
+func copyDirFromContainer(profile, cluster, taskID, containerName, region, remotePath, localPath string) error {
+	archive := fmt.Sprintf("/tmp/.infra-cp-%d.tar", time.Now().UnixNano())
+	removeArchive := func() {
+		RunECSCommand(profile, cluster, taskID, containerName, region, "rm -f "+shellQuote(archive), io.Discard)
+	}
+
+	var output bytes.Buffer
+	command := fmt.Sprintf("tar -cf %s -C %s .", shellQuote(archive), shellQuote(remotePath))
+	status, err := RunECSCommand(profile, cluster, taskID, containerName, region, command, &output)
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		removeArchive()
+		return fmt.Errorf("tar exited with status %d in the container (is tar installed?): %s", status, strings.TrimSpace(output.String()))
+	}
+
+	size, remoteSum, err := statContainerFile(profile, cluster, taskID, containerName, region, archive)
+	if err != nil {
+		removeArchive()
+		return err
+	}
+	local, err := os.CreateTemp("", "infra-cp-*.tar")
+	if err != nil {
+		removeArchive()
+		return fmt.Errorf("failed to create local archive: %v", err)
+	}
+	local.Close()
+	defer os.Remove(local.Name())
+
+	if err := downloadFile(profile, cluster, taskID, containerName, region, archive, local.Name(), filepath.Base(remotePath)+".tar", size, remoteSum, true); err != nil {
+		return err
+	}
+	if err := extractTar(local.Name(), localPath); err != nil {
+		return err
+	}
+	fmt.Printf("Copied %s (%s archive, sha256 %s)\n", localPath, utils.FormatBytes(size), remoteSum)
+	return nil
+}
+
+// Streams a container file of the given size and checksum into localPath through a temporary file
+// beside it, verifying both. With removeRemote the container file is deleted once it has been read.
+func downloadFile(profile, cluster, taskID, containerName, region, remotePath, localPath, label string, size int64, remoteSum string, removeRemote bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), ".infra-cp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	progress := utils.NewProgress(label, size)
+	decoder := &base64LineDecoder{dst: io.MultiWriter(tmp, hasher), progress: progress}
+
+	command := "base64 " + shellQuote(remotePath)
+	if removeRemote {
+		// The exit marker follows the command, so the shell must not exit before it
+		command = fmt.Sprintf("base64 %s; status=$?; rm -f %s; [ $status -eq 0 ]", shellQuote(remotePath), shellQuote(remotePath))
+	}
+	status, err := RunECSCommand(profile, cluster, taskID, containerName, region, command, decoder)
+	progress.Finish()
+	if err != nil {
+		return err
+	}
+	if decoder.err != nil {
+		return fmt.Errorf("failed to decode %s: %v", remotePath, decoder.err)
+	}
+	if status != 0 {
+		return fmt.Errorf("base64 exited with status %d in the container (is base64 installed?)", status)
+	}
+
+	if decoder.written != size {
+		return fmt.Errorf("copied %d bytes but %s is %d bytes", decoder.written, remotePath, size)
+	}
+	if localSum := hex.EncodeToString(hasher.Sum(nil)); localSum != remoteSum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", remotePath, remoteSum, localSum)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write local file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), localPath); err != nil {
+		return fmt.Errorf("failed to write local file: %v", err)
+	}
+	return nil
+}
+
+// Copies a file or directory into a container over ECS exec, verifying its SHA-256 checksum.
+// Directories are sent as a tar archive and unpacked into remotePath.
+func CopyToContainer(profile, cluster, taskID, containerName, region, localPath, remotePath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %v", err)
+	}
+	if info.IsDir() {
+		return copyDirToContainer(profile, cluster, taskID, containerName, region, localPath, remotePath)
+	}
+
+	size, localSum, err := uploadFile(profile, cluster, taskID, containerName, region, localPath, remotePath, filepath.Base(localPath))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Copied %s (%s, sha256 %s)\n", remotePath, utils.FormatBytes(size), localSum)
+	return nil
+}
+
+// Archives a local directory with tar, uploads the archive beside remotePath and unpacks it there
+func copyDirToContainer(profile, cluster, taskID, containerName, region, localPath, remotePath string) error {
+	local, err := os.CreateTemp("", "infra-cp-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create local archive: %v", err)
+	}
+	defer os.Remove(local.Name())
+	if err := writeTar(local, localPath); err != nil {
+		local.Close()
+		return fmt.Errorf("failed to archive %s: %v", localPath, err)
+	}
+	if err := local.Close(); err != nil {
+		return fmt.Errorf("failed to archive %s: %v", localPath, err)
+	}
+
+	archive := strings.TrimRight(remotePath, "/") + ".infra-tmp.tar"
+	size, localSum, err := uploadFile(profile, cluster, taskID, containerName, region, local.Name(), archive, filepath.Base(localPath)+".tar")
+	if err != nil {
+		return err
+	}
+
+	// The exit marker follows the command, so the shell must not exit before it
+	var output bytes.Buffer
+	command := fmt.Sprintf("mkdir -p %s && tar -xf %s -C %s; status=$?; rm -f %s; [ $status -eq 0 ]",
+		shellQuote(remotePath), shellQuote(archive), shellQuote(remotePath), shellQuote(archive))
+	status, err := RunECSCommand(profile, cluster, taskID, containerName, region, command, &output)
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		return fmt.Errorf("unpacking into %s exited with status %d in the container (is tar installed?): %s", remotePath, status, strings.TrimSpace(output.String()))
+	}
+	fmt.Printf("Copied %s (%s archive, sha256 %s)\n", remotePath, utils.FormatBytes(size), localSum)
+	return nil
+}
+
+// Streams a local file into remotePath through a temporary file beside it, and returns its size and
+// checksum once the container's copy has been verified against them
+func uploadFile(profile, cluster, taskID, containerName, region, localPath, remotePath, label string) (int64, string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read local file: %v", err)
+	}
+	size := info.Size()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return 0, "", fmt.Errorf("failed to read local file: %v", err)
+	}
+	localSum := hex.EncodeToString(hasher.Sum(nil))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, "", fmt.Errorf("failed to read local file: %v", err)
+	}
+
+	// Each full line carries base64LineBytes of input as 76 characters plus a newline
+	encodedLen := int64(base64.StdEncoding.EncodedLen(int(size)))
+	lines := (size + base64LineBytes - 1) / base64LineBytes
+	inputLen := encodedLen + lines
+
+	progress := utils.NewProgress(label, size)
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(encodeBase64Lines(writer, f, progress))
+	}()
+
+	tmpPath := remotePath + ".infra-tmp"
+	command := fmt.Sprintf("head -c %d | base64 -d > %s && mv %s %s",
+		inputLen, shellQuote(tmpPath), shellQuote(tmpPath), shellQuote(remotePath))
+
+	var output bytes.Buffer
+	status, err := RunECSCommandWithInput(profile, cluster, taskID, containerName, region, command, reader, &output)
+	progress.Finish()
+	if err != nil {
+		return 0, "", err
+	}
+	if status != 0 {
+		return 0, "", fmt.Errorf("writing %s in the container exited with status %d: %s", remotePath, status, strings.TrimSpace(output.String()))
+	}
+
+	remoteSize, remoteSum, err := statContainerFile(profile, cluster, taskID, containerName, region, remotePath)
+	if err != nil {
+		return 0, "", err
+	}
+	if remoteSize != size || remoteSum != localSum {
+		return 0, "", fmt.Errorf("checksum mismatch for %s: expected %s (%d bytes), got %s (%d bytes)", remotePath, localSum, size, remoteSum, remoteSize)
+	}
+	return size, localSum, nil
+}
+
+// Returns the size and SHA-256 checksum of a file inside a container, or errRemoteDirectory
+// if the path is a directory
+func statContainerFile(profile, cluster, taskID, containerName, region, path string) (int64, string, error) {
+	var output bytes.Buffer
+	command := fmt.Sprintf("if [ -d %s ]; then echo directory; else wc -c < %s && sha256sum %s; fi", shellQuote(path), shellQuote(path), shellQuote(path))
+	status, err := RunECSCommand(profile, cluster, taskID, containerName, region, command, &output)
+	if err != nil {
+		return 0, "", err
+	}
+	if status != 0 {
+		return 0, "", fmt.Errorf("failed to read %s in the container: %s", path, strings.TrimSpace(output.String()))
+	}
+
+	fields := strings.Fields(output.String())
+	if len(fields) == 1 && fields[0] == "directory" {
+		return 0, "", errRemoteDirectory
+	}
+	if len(fields) < 2 {
+		return 0, "", fmt.Errorf("unexpected output checking %s: %q", path, output.String())
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("unexpected size for %s: %q", path, fields[0])
+	}
+	return size, fields[1], nil
+}
+
+// Writes the contents of dir to w as a tar archive of regular files, directories and symlinks
+func writeTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		link := ""
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Unpacks a tar archive into dir, refusing entries that would land outside it. Symlinks are
+// created last so that no file in the archive is written through one.
+func extractTar(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %v", err)
+	}
+	defer f.Close()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	var links []*tar.Header
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %v", err)
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(header.Name, "./"))
+		if name == "" || name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %s is outside the destination", header.Name)
+		}
+		target := filepath.Join(dir, name)
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0o700); err != nil {
+				return fmt.Errorf("failed to create %s: %v", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return fmt.Errorf("failed to create %s: %v", filepath.Dir(target), err)
+			}
+			out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %v", target, err)
+			}
+			_, err = io.Copy(out, tr)
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %v", target, err)
+			}
+		case tar.TypeSymlink:
+			links = append(links, header)
+		}
+	}
+	for _, header := range links {
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(header.Name, "./")))
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return fmt.Errorf("failed to create link %s: %v", target, err)
+		}
+	}
+	return nil
+}
+
+// Writes src to w as base64 in 76-column lines
+func encodeBase64Lines(w io.Writer, src io.Reader, progress *utils.Progress) error {
+	buf := make([]byte, base64LineBytes*1024)
+	line := make([]byte, base64.StdEncoding.EncodedLen(base64LineBytes)+1)
+	for {
+		n, err := io.ReadFull(src, buf)
+		for start := 0; start < n; start += base64LineBytes {
+			end := start + base64LineBytes
+			if end > n {
+				end = n
+			}
+			encoded := base64.StdEncoding.EncodedLen(end - start)
+			base64.StdEncoding.Encode(line, buf[start:end])
+			line[encoded] = '\n'
+			if _, werr := w.Write(line[:encoded+1]); werr != nil {
+				return werr
+			}
+			progress.Add(end - start)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// base64LineDecoder decodes base64 output line by line as it is streamed
+type base64LineDecoder struct {
+	dst      io.Writer
+	progress *utils.Progress
+	buf      []byte
+	written  int64
+	err      error
+}
+
+func (d *base64LineDecoder) Write(p []byte) (int, error) {
+	d.buf = append(d.buf, p...)
+	for d.err == nil {
+		i := bytes.IndexByte(d.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSpace(d.buf[:i])
+		d.buf = d.buf[i+1:]
+		if len(line) == 0 {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			d.err = err
+			break
+		}
+		if _, err := d.dst.Write(decoded); err != nil {
+			d.err = err
+			break
+		}
+		d.written += int64(len(decoded))
+		d.progress.Add(len(decoded))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,251 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"raid/infra/internal/utils"
+)
+
+// How often deployment progress is polled
+const deploymentPollInterval = 10 * time.Second
+
+// Updates an ECS service with the given update-service request and returns the updated service
+func UpdateECSService(cluster, service, profile, region string, input *ecssdk.UpdateServiceInput) (*Service, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	input.Cluster = awssdk.String(cluster)
+	input.Service = awssdk.String(service)
+	output, err := client.UpdateService(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ECS service: %v", err)
+	}
+	return serviceFromSDK(*output.Service), nil
+}
+
+// Enables execute command on a service and forces a new deployment, returning the new deployment's ID
+func EnableExecuteCommand(cluster, service, profile, region string) (string, error) {
+	return startDeployment(cluster, service, profile, region, &ecssdk.UpdateServiceInput{
+		EnableExecuteCommand: awssdk.Bool(true),
+		ForceNewDeployment:   true,
+	})
+}
+
+// Forces a new deployment of a service with its current task definition, returning the new deployment's ID
+func ForceNewDeployment(cluster, service, profile, region string) (string, error) {
+	return startDeployment(cluster, service, profile, region, &ecssdk.UpdateServiceInput{ForceNewDeployment: true})
+}
+
+// Deploys a task definition revision to a service, returning the new deployment's ID
+func DeployTaskDefinition(cluster, service, taskDefinition, profile, region string) (string, error) {
+	return startDeployment(cluster, service, profile, region, &ecssdk.UpdateServiceInput{TaskDefinition: awssdk.String(taskDefinition)})
+}
+
+// Updates a service with the given update-service request and returns the ID of its new primary deployment
+func startDeployment(cluster, service, profile, region string, input *ecssdk.UpdateServiceInput) (string, error) {
+	updated, err := UpdateECSService(cluster, service, profile, region, input)
+	if err != nil {
+		return "", err
+	}
+	primary := updated.PrimaryDeployment()
+	if primary == nil {
+		return "", fmt.Errorf("service %s has no primary deployment", service)
+	}
+	return primary.ID, nil
+}
+
+// Sets the desired task count of a service
+func SetDesiredCount(cluster, service string, count int, profile, region string) error {
+	_, err := UpdateECSService(cluster, service, profile, region, &ecssdk.UpdateServiceInput{DesiredCount: awssdk.Int32(int32(count))})
+	return err
+}
+
+// Waits until a service's desired and running task counts equal count with no tasks pending, printing progress
+func WaitForRunningCount(cluster, service string, count int, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		svc, err := DescribeECSService(cluster, service, profile, region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] %s: running %d/%d, pending %d\n", time.Now().Format("15:04:05"), service, svc.RunningCount, svc.DesiredCount, svc.PendingCount)
+
+		if svc.DesiredCount != count {
+			return fmt.Errorf("desired count of %s changed to %d while waiting for %d", service, svc.DesiredCount, count)
+		}
+		if svc.RunningCount == count && svc.PendingCount == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %d running task(s) in %s", timeout, count, service)
+		}
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// Waits until a service's running count equals its desired count with no tasks pending, following
+// the desired count if something else, such as auto scaling, changes it while waiting
+func WaitForDesiredCount(cluster, service, profile, region string, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		svc, err := DescribeECSService(cluster, service, profile, region)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Printf("[%s] %s: running %d/%d, pending %d\n", time.Now().Format("15:04:05"), service, svc.RunningCount, svc.DesiredCount, svc.PendingCount)
+
+		if svc.RunningCount == svc.DesiredCount && svc.PendingCount == 0 {
+			return svc.DesiredCount, nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timed out after %s waiting for %s to run its desired %d task(s)", timeout, service, svc.DesiredCount)
+		}
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// Lists the IDs of the tasks started by a deployment
+func GetDeploymentTasks(cluster, deploymentID, profile, region string) ([]string, error) {
+	taskIDs, err := listTaskIDs(profile, region, &ecssdk.ListTasksInput{
+		Cluster:   awssdk.String(cluster),
+		StartedBy: awssdk.String(deploymentID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECS tasks: %v", err)
+	}
+	return taskIDs, nil
+}
+
+// Lists the IDs of the tasks started by a deployment that have stopped or are stopping
+func GetStoppedDeploymentTasks(cluster, deploymentID, profile, region string) ([]string, error) {
+	taskIDs, err := listTaskIDs(profile, region, &ecssdk.ListTasksInput{
+		Cluster:       awssdk.String(cluster),
+		StartedBy:     awssdk.String(deploymentID),
+		DesiredStatus: ecstypes.DesiredStatusStopped,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stopped ECS tasks: %v", err)
+	}
+	return taskIDs, nil
+}
+
+// Follows a deployment until it is the service's only deployment and all of its tasks are running,
+// printing task replacement progress and why any of its tasks stopped. Returns an error if the
+// deployment fails, is rolled back by the circuit breaker, or is replaced by another deployment.
+func FollowDeployment(cluster, service, deploymentID, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	reported := map[string]bool{}
+	for {
+		svc, err := DescribeECSService(cluster, service, profile, region)
+		if err != nil {
+			return err
+		}
+		var deployment *Deployment
+		for i := range svc.Deployments {
+			if svc.Deployments[i].ID == deploymentID {
+				deployment = &svc.Deployments[i]
+			}
+		}
+		primary := svc.PrimaryDeployment()
+
+		// Report tasks of this deployment that stopped since the last poll
+		stopped, err := GetStoppedDeploymentTasks(cluster, deploymentID, profile, region)
+		if err != nil {
+			return err
+		}
+		var unreported []string
+		for _, taskID := range stopped {
+			if !reported[taskID] {
+				unreported = append(unreported, taskID)
+			}
+		}
+		if len(unreported) > 0 {
+			tasks, err := DescribeECSTasks(cluster, unreported, profile, region)
+			if err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				reported[task.ID()] = true
+				fmt.Printf("[%s] task %s stopped: %s\n", time.Now().Format("15:04:05"), task.ID(), task.StopSummary())
+			}
+		}
+
+		switch {
+		case deployment == nil:
+			return fmt.Errorf("deployment %s is no longer active on service %s", deploymentID, service)
+		case deployment.Status != "PRIMARY" && deployment.RolloutState == "FAILED" && primary != nil:
+			return fmt.Errorf("deployment %s failed and the circuit breaker rolled back to %s: %s",
+				deploymentID, primary.TaskDefinition, deployment.RolloutStateReason)
+		case deployment.Status != "PRIMARY":
+			return fmt.Errorf("deployment %s was replaced by a newer deployment", deploymentID)
+		}
+
+		fmt.Printf("[%s] %s: %s, running %d/%d, pending %d, failed %d, deployments %d\n",
+			time.Now().Format("15:04:05"), deployment.ID, utils.ValueOrDash(deployment.RolloutState),
+			deployment.RunningCount, deployment.DesiredCount, deployment.PendingCount, deployment.FailedTasks, len(svc.Deployments))
+
+		switch {
+		case deployment.RolloutState == "FAILED":
+			return fmt.Errorf("deployment %s failed: %s", deployment.ID, deployment.RolloutStateReason)
+		case len(svc.Deployments) == 1 && deployment.RunningCount == deployment.DesiredCount &&
+			(deployment.RolloutState == "COMPLETED" || deployment.RolloutState == ""):
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for service %s to become stable", timeout, service)
+		}
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// Waits until every container with an ExecuteCommandAgent in the deployment's running tasks reports it as
+// RUNNING. Sidecars without the agent are not counted, but a running task with no agent at all is an error.
+func WaitForExecAgents(cluster, deploymentID, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		taskIDs, err := GetDeploymentTasks(cluster, deploymentID, profile, region)
+		if err != nil {
+			return err
+		}
+		tasks, err := DescribeECSTasks(cluster, taskIDs, profile, region)
+		if err != nil {
+			return err
+		}
+
+		total, running := 0, 0
+		for _, task := range tasks {
+			agents := 0
+			for _, container := range task.Containers {
+				status := container.ExecAgentStatus()
+				if status == "" {
+					continue
+				}
+				agents++
+				if status == "RUNNING" {
+					running++
+				}
+			}
+			if agents == 0 && task.LastStatus == "RUNNING" {
+				return fmt.Errorf("task %s is running without an %s; was it started with exec enabled?", task.ID(), ExecuteCommandAgent)
+			}
+			total += agents
+		}
+		fmt.Printf("[%s] exec agents RUNNING: %d/%d containers across %d task(s)\n", time.Now().Format("15:04:05"), running, total, len(tasks))
+
+		if total > 0 && running == total {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for exec agents to report RUNNING", timeout)
+		}
+		time.Sleep(deploymentPollInterval)
+	}
+}
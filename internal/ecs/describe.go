@@ -0,0 +1,642 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"raid/infra/internal/utils"
+)
+
+// Name of the managed agent that serves ECS exec sessions
+const ExecuteCommandAgent = "ExecuteCommandAgent"
+
+// Cluster is the subset of an ECS cluster description used by infra
+type Cluster struct {
+	ClusterName   string
+	Configuration ClusterConfiguration
+}
+
+// ClusterConfiguration holds the cluster's execute command configuration
+type ClusterConfiguration struct {
+	ExecuteCommandConfiguration ExecuteCommandConfiguration
+}
+
+// ExecuteCommandConfiguration controls encryption and logging of ECS exec sessions
+type ExecuteCommandConfiguration struct {
+	KmsKeyID         string
+	Logging          string
+	LogConfiguration ExecuteCommandLogConfiguration
+}
+
+// ExecuteCommandLogConfiguration is where ECS exec session output is logged when logging is OVERRIDE
+type ExecuteCommandLogConfiguration struct {
+	CloudWatchLogGroupName string
+	S3BucketName           string
+	S3KeyPrefix            string
+}
+
+// Service is the subset of an ECS service description used by infra
+type Service struct {
+	ServiceName              string
+	ServiceArn               string
+	TaskDefinition           string
+	LaunchType               string
+	CapacityProviderStrategy []CapacityProviderStrategyItem
+	PlatformVersion          string
+	EnableExecuteCommand     bool
+	NetworkConfiguration     NetworkConfiguration
+	DesiredCount             int
+	RunningCount             int
+	PendingCount             int
+	LoadBalancers            []LoadBalancer
+	DeploymentConfiguration  DeploymentConfiguration
+	Deployments              []Deployment
+	Events                   []ServiceEvent
+}
+
+// DeploymentConfiguration controls how a service rolls out new deployments
+type DeploymentConfiguration struct {
+	DeploymentCircuitBreaker DeploymentCircuitBreaker
+}
+
+// DeploymentCircuitBreaker fails a deployment whose tasks keep failing to start, optionally rolling it back
+type DeploymentCircuitBreaker struct {
+	Enable   bool
+	Rollback bool
+}
+
+// CapacityProviderStrategyItem is a capacity provider a service or task places its tasks on
+type CapacityProviderStrategyItem struct {
+	CapacityProvider string
+	Weight           int
+	Base             int
+}
+
+// LoadBalancer is a target group a service registers its tasks with
+type LoadBalancer struct {
+	TargetGroupArn   string
+	LoadBalancerName string
+	ContainerName    string
+	ContainerPort    int
+}
+
+// Deployment is one of a service's deployments
+type Deployment struct {
+	ID                 string
+	Status             string
+	TaskDefinition     string
+	DesiredCount       int
+	PendingCount       int
+	RunningCount       int
+	FailedTasks        int
+	RolloutState       string
+	RolloutStateReason string
+	CreatedAt          string
+	UpdatedAt          string
+}
+
+// ServiceEvent is an entry in a service's event log
+type ServiceEvent struct {
+	ID        string
+	CreatedAt string
+	Message   string
+}
+
+// NetworkConfiguration is the awsvpc network configuration of a service or task
+type NetworkConfiguration struct {
+	AwsvpcConfiguration AwsvpcConfiguration
+}
+
+// AwsvpcConfiguration holds the subnets and security groups of an awsvpc service
+type AwsvpcConfiguration struct {
+	Subnets        []string
+	SecurityGroups []string
+	AssignPublicIP string
+}
+
+// Task is the subset of an ECS task description used by infra
+type Task struct {
+	TaskArn              string
+	TaskDefinitionArn    string
+	LastStatus           string
+	Group                string
+	StartedBy            string
+	CreatedAt            string
+	StartedAt            string
+	StoppedAt            string
+	StopCode             string
+	StoppedReason        string
+	LaunchType           string
+	ContainerInstanceArn string
+	PlatformVersion      string
+	PlatformFamily       string
+	EnableExecuteCommand bool
+	Containers           []Container
+	Attachments          []Attachment
+	Overrides            TaskOverride
+}
+
+// Container is a running container within an ECS task
+type Container struct {
+	Name          string
+	RuntimeID     string
+	LastStatus    string
+	ExitCode      *int
+	Reason        string
+	ManagedAgents []ManagedAgent
+}
+
+// ManagedAgent is an agent ECS runs alongside a container, such as the ExecuteCommandAgent
+type ManagedAgent struct {
+	Name       string
+	LastStatus string
+	Reason     string
+}
+
+// Attachment is a resource attached to a task, such as its elastic network interface
+type Attachment struct {
+	Type    string
+	Details []KeyValue
+}
+
+// KeyValue is a name/value pair as returned by the ECS API
+type KeyValue struct {
+	Name  string
+	Value string
+}
+
+// TaskOverride holds the overrides a task was started with
+type TaskOverride struct {
+	TaskRoleArn        string
+	ContainerOverrides []ContainerOverride
+}
+
+// TaskDefinition is the subset of an ECS task definition used by infra
+type TaskDefinition struct {
+	TaskDefinitionArn    string
+	Family               string
+	Revision             int
+	Cpu                  string
+	Memory               string
+	TaskRoleArn          string
+	ExecutionRoleArn     string
+	NetworkMode          string
+	ContainerDefinitions []ContainerDefinition
+}
+
+// ContainerDefinition is a container entry within an ECS task definition
+type ContainerDefinition struct {
+	Name              string
+	Image             string
+	Essential         *bool
+	Cpu               int
+	Memory            *int
+	MemoryReservation *int
+	Environment       []KeyValue
+	EnvironmentFiles  []EnvironmentFile
+	Secrets           []Secret
+	PortMappings      []PortMapping
+	LogConfiguration  *LogConfiguration
+}
+
+// Secret is an environment variable whose value ECS reads from SSM Parameter Store or Secrets Manager
+type Secret struct {
+	Name      string
+	ValueFrom string
+}
+
+// EnvironmentFile is an S3 object of environment variables loaded into a container
+type EnvironmentFile struct {
+	Value string
+	Type  string
+}
+
+// LogConfiguration is a container's log driver and its options
+type LogConfiguration struct {
+	LogDriver string
+	Options   map[string]string
+}
+
+// PortMapping is a container port declared in a task definition
+type PortMapping struct {
+	Name          string
+	ContainerPort int
+	Protocol      string
+}
+
+// Returns the status of the container's ExecuteCommandAgent, or "" if it has none
+func (c Container) ExecAgentStatus() string {
+	for _, agent := range c.ManagedAgents {
+		if agent.Name == ExecuteCommandAgent {
+			return agent.LastStatus
+		}
+	}
+	return ""
+}
+
+// Returns why a stopped task stopped, including the exit code and reason of each container that exited
+func (t *Task) StopSummary() string {
+	summary := utils.ValueOrDash(t.StoppedReason)
+	for _, c := range t.Containers {
+		switch {
+		case c.ExitCode != nil && c.Reason != "":
+			summary += fmt.Sprintf("; %s exited %d: %s", c.Name, *c.ExitCode, c.Reason)
+		case c.ExitCode != nil:
+			summary += fmt.Sprintf("; %s exited %d", c.Name, *c.ExitCode)
+		case c.Reason != "":
+			summary += fmt.Sprintf("; %s: %s", c.Name, c.Reason)
+		}
+	}
+	return summary
+}
+
+// Reports whether the container is essential, which is the default when unset
+func (c *ContainerDefinition) IsEssential() bool {
+	return c.Essential == nil || *c.Essential
+}
+
+// Returns the container definition with the given name, or nil if there is none
+func (td *TaskDefinition) Container(name string) *ContainerDefinition {
+	for i := range td.ContainerDefinitions {
+		if td.ContainerDefinitions[i].Name == name {
+			return &td.ContainerDefinitions[i]
+		}
+	}
+	return nil
+}
+
+// Returns the CloudWatch log group, region and stream the container writes to in a task,
+// as configured by the awslogs log driver
+func (c *ContainerDefinition) AWSLogsStream(taskID, defaultRegion string) (string, string, string, error) {
+	if c.LogConfiguration == nil || c.LogConfiguration.LogDriver != "awslogs" {
+		driver := "no log driver"
+		if c.LogConfiguration != nil {
+			driver = c.LogConfiguration.LogDriver
+		}
+		return "", "", "", fmt.Errorf("container %s uses %s, not awslogs", c.Name, driver)
+	}
+	options := c.LogConfiguration.Options
+	group := options["awslogs-group"]
+	prefix := options["awslogs-stream-prefix"]
+	if group == "" || prefix == "" {
+		return "", "", "", fmt.Errorf("container %s has no awslogs-group or awslogs-stream-prefix", c.Name)
+	}
+	region := options["awslogs-region"]
+	if region == "" {
+		region = defaultRegion
+	}
+	return group, region, fmt.Sprintf("%s/%s/%s", prefix, c.Name, taskID), nil
+}
+
+// Returns the ID of the task from its ARN
+func (t *Task) ID() string {
+	return t.TaskArn[strings.LastIndex(t.TaskArn, "/")+1:]
+}
+
+// Returns the name of the service that started the task, or "" for standalone tasks
+func (t *Task) ServiceName() string {
+	if !strings.HasPrefix(t.Group, "service:") {
+		return ""
+	}
+	return strings.TrimPrefix(t.Group, "service:")
+}
+
+// Returns the family:revision of the task's task definition
+func (t *Task) TaskDefinitionName() string {
+	return TaskDefinitionName(t.TaskDefinitionArn)
+}
+
+// Returns family:revision from a task definition ARN
+func TaskDefinitionName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
+// Returns the subnet of the task's elastic network interface, or "" for non-awsvpc tasks
+func (t *Task) SubnetID() string {
+	for _, attachment := range t.Attachments {
+		if attachment.Type != "ElasticNetworkInterface" {
+			continue
+		}
+		for _, detail := range attachment.Details {
+			if detail.Name == "subnetId" {
+				return detail.Value
+			}
+		}
+	}
+	return ""
+}
+
+// Fetches an ECS cluster including its execute command configuration
+func DescribeECSCluster(cluster, profile, region string) (*Cluster, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeClusters(context.TODO(), &ecssdk.DescribeClustersInput{
+		Clusters: []string{cluster},
+		Include:  []ecstypes.ClusterField{ecstypes.ClusterFieldConfigurations},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ECS cluster: %v", err)
+	}
+	if len(output.Clusters) == 0 {
+		return nil, fmt.Errorf("ECS cluster %s not found", cluster)
+	}
+	return clusterFromSDK(output.Clusters[0]), nil
+}
+
+// Fetches an ECS service
+func DescribeECSService(cluster, service, profile, region string) (*Service, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeServices(context.TODO(), &ecssdk.DescribeServicesInput{
+		Cluster:  awssdk.String(cluster),
+		Services: []string{service},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ECS service: %v", err)
+	}
+	if len(output.Services) == 0 {
+		return nil, fmt.Errorf("ECS service %s not found", service)
+	}
+	return serviceFromSDK(output.Services[0]), nil
+}
+
+// Fetches the full description of an ECS task
+func DescribeECSTask(cluster, taskID, profile, region string) (*Task, error) {
+	tasks, err := DescribeECSTasks(cluster, []string{taskID}, profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("ECS task %s not found", taskID)
+	}
+	return &tasks[0], nil
+}
+
+// Fetches the full descriptions of several ECS tasks, in batches of 100
+func DescribeECSTasks(cluster string, taskIDs []string, profile, region string) ([]Task, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for start := 0; start < len(taskIDs); start += 100 {
+		end := start + 100
+		if end > len(taskIDs) {
+			end = len(taskIDs)
+		}
+		output, err := client.DescribeTasks(context.TODO(), &ecssdk.DescribeTasksInput{
+			Cluster: awssdk.String(cluster),
+			Tasks:   taskIDs[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe ECS tasks: %v", err)
+		}
+		for _, task := range output.Tasks {
+			tasks = append(tasks, *taskFromSDK(task))
+		}
+	}
+	return tasks, nil
+}
+
+// Returns the service's PRIMARY deployment, or nil if it has none
+func (s *Service) PrimaryDeployment() *Deployment {
+	for i := range s.Deployments {
+		if s.Deployments[i].Status == "PRIMARY" {
+			return &s.Deployments[i]
+		}
+	}
+	return nil
+}
+
+// Fetches an ECS task definition by family, family:revision or ARN
+func DescribeTaskDefinition(taskDefinition, profile, region string) (*TaskDefinition, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeTaskDefinition(context.TODO(), &ecssdk.DescribeTaskDefinitionInput{
+		TaskDefinition: awssdk.String(taskDefinition),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ECS task definition: %v", err)
+	}
+	return taskDefinitionFromSDK(output.TaskDefinition), nil
+}
+
+// Formats an API timestamp for display, or returns "" if it is unset
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// Converts an optional API integer, keeping nil as nil
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+func keyValuesFromSDK(pairs []ecstypes.KeyValuePair) []KeyValue {
+	var values []KeyValue
+	for _, pair := range pairs {
+		values = append(values, KeyValue{Name: awssdk.ToString(pair.Name), Value: awssdk.ToString(pair.Value)})
+	}
+	return values
+}
+
+func clusterFromSDK(c ecstypes.Cluster) *Cluster {
+	cluster := &Cluster{ClusterName: awssdk.ToString(c.ClusterName)}
+	if c.Configuration != nil && c.Configuration.ExecuteCommandConfiguration != nil {
+		execConfig := c.Configuration.ExecuteCommandConfiguration
+		cluster.Configuration.ExecuteCommandConfiguration = ExecuteCommandConfiguration{
+			KmsKeyID: awssdk.ToString(execConfig.KmsKeyId),
+			Logging:  string(execConfig.Logging),
+		}
+		if logConfig := execConfig.LogConfiguration; logConfig != nil {
+			cluster.Configuration.ExecuteCommandConfiguration.LogConfiguration = ExecuteCommandLogConfiguration{
+				CloudWatchLogGroupName: awssdk.ToString(logConfig.CloudWatchLogGroupName),
+				S3BucketName:           awssdk.ToString(logConfig.S3BucketName),
+				S3KeyPrefix:            awssdk.ToString(logConfig.S3KeyPrefix),
+			}
+		}
+	}
+	return cluster
+}
+
+func serviceFromSDK(s ecstypes.Service) *Service {
+	service := &Service{
+		ServiceName:          awssdk.ToString(s.ServiceName),
+		ServiceArn:           awssdk.ToString(s.ServiceArn),
+		TaskDefinition:       awssdk.ToString(s.TaskDefinition),
+		LaunchType:           string(s.LaunchType),
+		PlatformVersion:      awssdk.ToString(s.PlatformVersion),
+		EnableExecuteCommand: s.EnableExecuteCommand,
+		NetworkConfiguration: networkConfigurationFromSDK(s.NetworkConfiguration),
+		DesiredCount:         int(s.DesiredCount),
+		RunningCount:         int(s.RunningCount),
+		PendingCount:         int(s.PendingCount),
+	}
+	for _, item := range s.CapacityProviderStrategy {
+		service.CapacityProviderStrategy = append(service.CapacityProviderStrategy, CapacityProviderStrategyItem{
+			CapacityProvider: awssdk.ToString(item.CapacityProvider),
+			Weight:           int(item.Weight),
+			Base:             int(item.Base),
+		})
+	}
+	for _, lb := range s.LoadBalancers {
+		service.LoadBalancers = append(service.LoadBalancers, LoadBalancer{
+			TargetGroupArn:   awssdk.ToString(lb.TargetGroupArn),
+			LoadBalancerName: awssdk.ToString(lb.LoadBalancerName),
+			ContainerName:    awssdk.ToString(lb.ContainerName),
+			ContainerPort:    int(awssdk.ToInt32(lb.ContainerPort)),
+		})
+	}
+	if s.DeploymentConfiguration != nil && s.DeploymentConfiguration.DeploymentCircuitBreaker != nil {
+		breaker := s.DeploymentConfiguration.DeploymentCircuitBreaker
+		service.DeploymentConfiguration.DeploymentCircuitBreaker = DeploymentCircuitBreaker{Enable: breaker.Enable, Rollback: breaker.Rollback}
+	}
+	for _, d := range s.Deployments {
+		service.Deployments = append(service.Deployments, Deployment{
+			ID:                 awssdk.ToString(d.Id),
+			Status:             awssdk.ToString(d.Status),
+			TaskDefinition:     awssdk.ToString(d.TaskDefinition),
+			DesiredCount:       int(d.DesiredCount),
+			PendingCount:       int(d.PendingCount),
+			RunningCount:       int(d.RunningCount),
+			FailedTasks:        int(d.FailedTasks),
+			RolloutState:       string(d.RolloutState),
+			RolloutStateReason: awssdk.ToString(d.RolloutStateReason),
+			CreatedAt:          formatTime(d.CreatedAt),
+			UpdatedAt:          formatTime(d.UpdatedAt),
+		})
+	}
+	for _, e := range s.Events {
+		service.Events = append(service.Events, ServiceEvent{
+			ID:        awssdk.ToString(e.Id),
+			CreatedAt: formatTime(e.CreatedAt),
+			Message:   awssdk.ToString(e.Message),
+		})
+	}
+	return service
+}
+
+func networkConfigurationFromSDK(n *ecstypes.NetworkConfiguration) NetworkConfiguration {
+	if n == nil || n.AwsvpcConfiguration == nil {
+		return NetworkConfiguration{}
+	}
+	return NetworkConfiguration{AwsvpcConfiguration: AwsvpcConfiguration{
+		Subnets:        n.AwsvpcConfiguration.Subnets,
+		SecurityGroups: n.AwsvpcConfiguration.SecurityGroups,
+		AssignPublicIP: string(n.AwsvpcConfiguration.AssignPublicIp),
+	}}
+}
+
+func taskFromSDK(t ecstypes.Task) *Task {
+	task := &Task{
+		TaskArn:              awssdk.ToString(t.TaskArn),
+		TaskDefinitionArn:    awssdk.ToString(t.TaskDefinitionArn),
+		LastStatus:           awssdk.ToString(t.LastStatus),
+		Group:                awssdk.ToString(t.Group),
+		StartedBy:            awssdk.ToString(t.StartedBy),
+		CreatedAt:            formatTime(t.CreatedAt),
+		StartedAt:            formatTime(t.StartedAt),
+		StoppedAt:            formatTime(t.StoppedAt),
+		StopCode:             string(t.StopCode),
+		StoppedReason:        awssdk.ToString(t.StoppedReason),
+		LaunchType:           string(t.LaunchType),
+		ContainerInstanceArn: awssdk.ToString(t.ContainerInstanceArn),
+		PlatformVersion:      awssdk.ToString(t.PlatformVersion),
+		PlatformFamily:       awssdk.ToString(t.PlatformFamily),
+		EnableExecuteCommand: t.EnableExecuteCommand,
+	}
+	for _, c := range t.Containers {
+		container := Container{
+			Name:       awssdk.ToString(c.Name),
+			RuntimeID:  awssdk.ToString(c.RuntimeId),
+			LastStatus: awssdk.ToString(c.LastStatus),
+			ExitCode:   intPtr(c.ExitCode),
+			Reason:     awssdk.ToString(c.Reason),
+		}
+		for _, agent := range c.ManagedAgents {
+			container.ManagedAgents = append(container.ManagedAgents, ManagedAgent{
+				Name:       string(agent.Name),
+				LastStatus: awssdk.ToString(agent.LastStatus),
+				Reason:     awssdk.ToString(agent.Reason),
+			})
+		}
+		task.Containers = append(task.Containers, container)
+	}
+	for _, a := range t.Attachments {
+		task.Attachments = append(task.Attachments, Attachment{Type: awssdk.ToString(a.Type), Details: keyValuesFromSDK(a.Details)})
+	}
+	if t.Overrides != nil {
+		task.Overrides.TaskRoleArn = awssdk.ToString(t.Overrides.TaskRoleArn)
+		for _, o := range t.Overrides.ContainerOverrides {
+			task.Overrides.ContainerOverrides = append(task.Overrides.ContainerOverrides, ContainerOverride{
+				Name:        awssdk.ToString(o.Name),
+				Command:     o.Command,
+				Environment: keyValuesFromSDK(o.Environment),
+			})
+		}
+	}
+	return task
+}
+
+func taskDefinitionFromSDK(td *ecstypes.TaskDefinition) *TaskDefinition {
+	if td == nil {
+		return &TaskDefinition{}
+	}
+	taskDef := &TaskDefinition{
+		TaskDefinitionArn: awssdk.ToString(td.TaskDefinitionArn),
+		Family:            awssdk.ToString(td.Family),
+		Revision:          int(td.Revision),
+		Cpu:               awssdk.ToString(td.Cpu),
+		Memory:            awssdk.ToString(td.Memory),
+		TaskRoleArn:       awssdk.ToString(td.TaskRoleArn),
+		ExecutionRoleArn:  awssdk.ToString(td.ExecutionRoleArn),
+		NetworkMode:       string(td.NetworkMode),
+	}
+	for _, c := range td.ContainerDefinitions {
+		def := ContainerDefinition{
+			Name:              awssdk.ToString(c.Name),
+			Image:             awssdk.ToString(c.Image),
+			Essential:         c.Essential,
+			Cpu:               int(c.Cpu),
+			Memory:            intPtr(c.Memory),
+			MemoryReservation: intPtr(c.MemoryReservation),
+			Environment:       keyValuesFromSDK(c.Environment),
+		}
+		for _, file := range c.EnvironmentFiles {
+			def.EnvironmentFiles = append(def.EnvironmentFiles, EnvironmentFile{Value: awssdk.ToString(file.Value), Type: string(file.Type)})
+		}
+		for _, secret := range c.Secrets {
+			def.Secrets = append(def.Secrets, Secret{Name: awssdk.ToString(secret.Name), ValueFrom: awssdk.ToString(secret.ValueFrom)})
+		}
+		for _, pm := range c.PortMappings {
+			def.PortMappings = append(def.PortMappings, PortMapping{
+				Name:          awssdk.ToString(pm.Name),
+				ContainerPort: int(awssdk.ToInt32(pm.ContainerPort)),
+				Protocol:      string(pm.Protocol),
+			})
+		}
+		if c.LogConfiguration != nil {
+			def.LogConfiguration = &LogConfiguration{LogDriver: string(c.LogConfiguration.LogDriver), Options: c.LogConfiguration.Options}
+		}
+		taskDef.ContainerDefinitions = append(taskDef.ContainerDefinitions, def)
+	}
+	return taskDef
+}
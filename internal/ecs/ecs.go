@@ -5,26 +5,41 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
-	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+
+	"raid/infra/internal/audit"
+	"raid/infra/internal/aws"
 	"raid/infra/internal/utils"
 )
 
+// Returns an ECS client for the given profile and region
+func newClient(profile, region string) (*ecssdk.Client, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	return ecssdk.NewFromConfig(cfg), nil
+}
+
 // Fetches the ECS clusters for a given profile
 func GetECSClusters(profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ecs", "list-clusters", "--query", "clusterArns", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+	client, err := newClient(profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch ECS clusters: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		return nil, err
+	}
+	var arns []string
+	paginator := ecssdk.NewListClustersPaginator(client, &ecssdk.ListClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ECS clusters: %v", err)
 		}
-		return nil, fmt.Errorf("failed to fetch ECS clusters: %v", err)
+		arns = append(arns, page.ClusterArns...)
 	}
-
-	arns := strings.Fields(strings.TrimSpace(string(output)))
 	if len(arns) == 0 {
 		return nil, fmt.Errorf("no ECS clusters available")
 	}
@@ -50,17 +65,19 @@ func SelectECSCluster(profile, region string) (string, error) {
 
 // Fetches the ECS services for a given cluster and profile
 func GetECSServices(cluster, profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ecs", "list-services", "--cluster", cluster, "--query", "serviceArns", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+	client, err := newClient(profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch ECS services: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		return nil, err
+	}
+	var arns []string
+	paginator := ecssdk.NewListServicesPaginator(client, &ecssdk.ListServicesInput{Cluster: awssdk.String(cluster)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ECS services: %v", err)
 		}
-		return nil, fmt.Errorf("failed to fetch ECS services: %v", err)
+		arns = append(arns, page.ServiceArns...)
 	}
-	arns := strings.Fields(strings.TrimSpace(string(output)))
 
 	if len(arns) == 0 {
 		return nil, fmt.Errorf("no ECS services available")
@@ -85,82 +102,94 @@ func SelectECSService(cluster, profile, region string) (string, error) {
 
 // Fetches the ECS tasks for a given cluster, service, and profile
 func GetECSTasks(cluster, service, profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ecs", "list-tasks", "--cluster", cluster, "--service-name", service, "--query", "taskArns", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+	taskIDs, err := listTaskIDs(profile, region, &ecssdk.ListTasksInput{
+		Cluster:     awssdk.String(cluster),
+		ServiceName: awssdk.String(service),
+	})
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch ECS tasks: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
 		return nil, fmt.Errorf("failed to fetch ECS tasks: %v", err)
 	}
-	arns := strings.Fields(strings.TrimSpace(string(output)))
 
-	if len(arns) == 0 {
+	if len(taskIDs) == 0 {
 		return nil, fmt.Errorf("no ECS tasks available")
 	}
-	var taskIDs []string
-	for _, arn := range arns {
-		parts := strings.Split(arn, "/")
-		taskIDs = append(taskIDs, parts[len(parts)-1]) // Extract task ID
-	}
 	return taskIDs, nil
 }
 
-// Prompts the user to select an ECS task
-func SelectECSTask(cluster, service, profile, region string) (string, error) {
-	tasks, err := GetECSTasks(cluster, service, profile, region)
+// Lists the IDs of every task matching a list-tasks request
+func listTaskIDs(profile, region string, input *ecssdk.ListTasksInput) ([]string, error) {
+	client, err := newClient(profile, region)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	return utils.PromptSelection(tasks, "ECS Task")
-}
-
-// Fetches the details for an ECS task
-func GetTaskDetails(cluster, taskID, profile, region string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ecs", "describe-tasks", "--cluster", cluster, "--tasks", taskID, "--query", "tasks[0].containers[0].runtimeId", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("failed to fetch ECS task details: %s", strings.TrimSpace(string(exitErr.Stderr)))
+	var arns []string
+	paginator := ecssdk.NewListTasksPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
 		}
-		return "", fmt.Errorf("failed to fetch ECS task details: %v", err)
+		arns = append(arns, page.TaskArns...)
 	}
-	return strings.TrimSpace(string(output)), nil
+	return taskIDsFromArns(arns), nil
 }
 
-// Fetches the container names for a given task
-func GetECSContainers(cluster, taskID, profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "ecs", "describe-tasks", "--cluster", cluster, "--tasks", taskID, "--query", "tasks[0].containers[].name", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+// Fetches the containers of a task that can be exec'd into. Sidecars without an ExecuteCommandAgent
+// are skipped, unless no container has one (the task was started without execute command).
+func GetECSContainers(cluster, taskID, profile, region string) ([]Container, error) {
+	task, err := DescribeECSTask(cluster, taskID, profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch ECS containers: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
-		return nil, fmt.Errorf("failed to fetch ECS containers: %v", err)
+		return nil, err
 	}
-	containers := strings.Fields(strings.TrimSpace(string(output)))
-
-	if len(containers) == 0 {
+	if len(task.Containers) == 0 {
 		return nil, fmt.Errorf("no ECS containers available")
 	}
-	return containers, nil
+
+	var withAgent []Container
+	for _, c := range task.Containers {
+		if c.ExecAgentStatus() != "" {
+			withAgent = append(withAgent, c)
+		}
+	}
+	if len(withAgent) == 0 {
+		return task.Containers, nil
+	}
+	return withAgent, nil
 }
 
-// Prompts the user to select an ECS container
-func SelectECSContainer(cluster, taskID, profile, region string) (string, error) {
+// Prompts the user to select an ECS container, returning its runtime ID and exec agent status
+func SelectECSContainer(cluster, taskID, profile, region string) (*Container, error) {
 	containers, err := GetECSContainers(cluster, taskID, profile, region)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if len(containers) == 1 {
+		fmt.Fprintf(os.Stderr, "Using container %s\n", containers[0].Name)
+		return &containers[0], nil
 	}
 
-	return utils.PromptSelection(containers, "ECS Container")
+	options := make([]string, len(containers))
+	for i, c := range containers {
+		options[i] = fmt.Sprintf("%s (exec agent %s)", c.Name, utils.ValueOrDash(c.ExecAgentStatus()))
+	}
+	selection, err := utils.PromptSelection(options, "ECS Container")
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return &containers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid container selection: %s", selection)
+}
+
+// Returns the SSM target of a container in a task
+func ContainerSSMTarget(cluster, taskID string, container *Container) (string, error) {
+	if container.RuntimeID == "" {
+		return "", fmt.Errorf("container %s has no runtime ID yet", container.Name)
+	}
+	return fmt.Sprintf("ecs:%s_%s_%s", cluster, taskID, container.RuntimeID), nil
 }
 
 // Starts an ECS exec session with shell
@@ -178,7 +207,15 @@ func StartECSExecSession(profile, cluster, taskID, containerName, region string)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	session := audit.Start(audit.Session{
+		Kind:      audit.KindECSExec,
+		Profile:   profile,
+		Region:    region,
+		Cluster:   cluster,
+		Task:      taskID,
+		Container: containerName,
+	})
+	err := session.Run(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to start ECS exec session: %v", err)
 	}
@@ -186,15 +223,18 @@ func StartECSExecSession(profile, cluster, taskID, containerName, region string)
 }
 
 // Starts an SSM session for port forwarding
-func StartECSSSMSession(profile, cluster, taskID, runtimeID, dbHost, region string, dbPort int) error {
+func StartECSSSMSession(profile, cluster, taskID string, container *Container, dbHost, region string, dbPort int) error {
+	target, err := ContainerSSMTarget(cluster, taskID, container)
+	if err != nil {
+		return err
+	}
+
 	// Prompt user for a local port number
 	localPort, err := utils.PromptLocalPortNumber()
 	if err != nil {
 		return err
 	}
 
-	
-	target := fmt.Sprintf("ecs:%s_%s_%s", cluster, taskID, runtimeID)
 	fmt.Printf("SSM Target: %s\n", target)
 
 	// Run the AWS CLI command to start the SSM session
@@ -207,9 +247,120 @@ func StartECSSSMSession(profile, cluster, taskID, runtimeID, dbHost, region stri
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err = cmd.Run()
+	session := audit.Start(audit.Session{
+		Kind:       audit.KindECSPortForward,
+		Profile:    profile,
+		Region:     region,
+		Cluster:    cluster,
+		Task:       taskID,
+		Container:  container.Name,
+		DBEndpoint: fmt.Sprintf("%s:%d", dbHost, dbPort),
+		LocalPort:  localPort,
+	})
+	err = session.Run(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to start SSM session: %v", err)
+	}
+	return nil
+}
+
+// Prompts the user to select one of the container's mapped ports, or enter another port
+func SelectContainerPort(cluster, taskID, containerName, profile, region string) (int, error) {
+	task, err := DescribeECSTask(cluster, taskID, profile, region)
+	if err != nil {
+		return 0, err
+	}
+	taskDef, err := DescribeTaskDefinition(task.TaskDefinitionArn, profile, region)
+	if err != nil {
+		return 0, err
+	}
+
+	const otherPort = "Other port (not in task definition)"
+	var options []string
+	ports := map[string]int{}
+	for _, def := range taskDef.ContainerDefinitions {
+		if def.Name != containerName {
+			continue
+		}
+		for _, pm := range def.PortMappings {
+			label := fmt.Sprintf("%d/%s", pm.ContainerPort, pm.Protocol)
+			if pm.Name != "" {
+				label = fmt.Sprintf("%s (%s)", label, pm.Name)
+			}
+			options = append(options, label)
+			ports[label] = pm.ContainerPort
+		}
+	}
+	options = append(options, otherPort)
+
+	selection, err := utils.PromptSelection(options, "Container Port")
+	if err != nil {
+		return 0, err
+	}
+	if selection != otherPort {
+		return ports[selection], nil
+	}
+
+	input, err := utils.PromptInput("Enter the container port to forward to", func(input string) error {
+		port, err := strconv.Atoi(input)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("port must be a number between 1 and 65535")
+		}
+		return nil
+	}, "")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(input)
+}
+
+// Starts an SSM session forwarding a local port to a port on the ECS container itself
+func StartECSContainerPortForwardSession(profile, cluster, taskID string, container *Container, region string, containerPort int) error {
+	target, err := ContainerSSMTarget(cluster, taskID, container)
+	if err != nil {
+		return err
+	}
+
+	localPort, err := utils.PromptLocalPortNumber()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("SSM Target: %s\n", target)
+	fmt.Printf("Forwarding localhost:%d to container port %d\n", localPort, containerPort)
+
+	cmd := exec.Command("aws", "ssm", "start-session",
+		"--target", target,
+		"--document-name", "AWS-StartPortForwardingSession",
+		"--parameters", fmt.Sprintf(`{"portNumber":["%d"],"localPortNumber":["%d"]}`, containerPort, localPort),
+		"--profile", profile, "--region", region)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	session := audit.Start(audit.Session{
+		Kind:       audit.KindECSContainerForward,
+		Profile:    profile,
+		Region:     region,
+		Cluster:    cluster,
+		Task:       taskID,
+		Container:  container.Name,
+		RemotePort: containerPort,
+		LocalPort:  localPort,
+	})
+	err = session.Run(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to start SSM session: %v", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Extracts task IDs from task ARNs
+func taskIDsFromArns(arns []string) []string {
+	taskIDs := make([]string, 0, len(arns))
+	for _, arn := range arns {
+		parts := strings.Split(arn, "/")
+		taskIDs = append(taskIDs, parts[len(parts)-1])
+	}
+	return taskIDs
+}
@@ -0,0 +1,76 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+
+	"raid/infra/internal/audit"
+)
+
+// Resolves the EC2 instance ID of the container instance an EC2 launch type task runs on
+func GetTaskInstanceID(cluster string, task *Task, profile, region string) (string, error) {
+	if task.ContainerInstanceArn == "" {
+		return "", fmt.Errorf("task %s does not run on a container instance", task.ID())
+	}
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := client.DescribeContainerInstances(context.TODO(), &ecssdk.DescribeContainerInstancesInput{
+		Cluster:            awssdk.String(cluster),
+		ContainerInstances: []string{task.ContainerInstanceArn},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe ECS container instance: %v", err)
+	}
+	if len(output.ContainerInstances) == 0 || awssdk.ToString(output.ContainerInstances[0].Ec2InstanceId) == "" {
+		return "", fmt.Errorf("container instance %s not found", task.ContainerInstanceArn)
+	}
+	return awssdk.ToString(output.ContainerInstances[0].Ec2InstanceId), nil
+}
+
+// Opens an SSM shell on a task's EC2 host that execs into the container by its runtime ID, using docker,
+// or containerd's ctr where docker is not installed. Used for tasks started without ECS exec.
+func StartECSHostExecSession(profile, cluster, taskID string, container *Container, instanceID, region string) error {
+	if container.RuntimeID == "" {
+		return fmt.Errorf("container %s has no runtime ID yet", container.Name)
+	}
+	shell := fmt.Sprintf("if command -v docker >/dev/null 2>&1; then sudo docker exec -it %[1]s sh; "+
+		"else sudo ctr -n moby task exec -t --exec-id infra-$$ %[1]s sh; fi", container.RuntimeID)
+	parameters, err := json.Marshal(map[string][]string{"command": {shell}})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Starting SSM session on host %s for container %s (%s)\n", instanceID, container.Name, container.RuntimeID)
+	cmd := exec.Command("aws", "ssm", "start-session",
+		"--target", instanceID,
+		"--document-name", "AWS-StartInteractiveCommand",
+		"--parameters", string(parameters),
+		"--profile", profile,
+		"--region", region)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	session := audit.Start(audit.Session{
+		Kind:      audit.KindECSHostExec,
+		Profile:   profile,
+		Region:    region,
+		Cluster:   cluster,
+		Task:      taskID,
+		Container: container.Name,
+		Instance:  instanceID,
+	})
+	if err := session.Run(cmd); err != nil {
+		return fmt.Errorf("failed to start SSM session on host %s: %v", instanceID, err)
+	}
+	return nil
+}
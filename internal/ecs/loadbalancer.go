@@ -0,0 +1,61 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+
+	"raid/infra/internal/aws"
+)
+
+// TargetHealthDescription is the health of one target registered with a target group
+type TargetHealthDescription struct {
+	Target       Target
+	TargetHealth TargetHealth
+}
+
+// Target is an IP address or instance registered with a target group
+type Target struct {
+	ID   string
+	Port int
+}
+
+// TargetHealth is a target's health check state and the reason for it
+type TargetHealth struct {
+	State       string
+	Reason      string
+	Description string
+}
+
+// Fetches the health of every target registered with a target group
+func GetTargetHealth(targetGroupArn, profile, region string) ([]TargetHealthDescription, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := elasticloadbalancingv2.NewFromConfig(cfg).DescribeTargetHealth(context.TODO(), &elasticloadbalancingv2.DescribeTargetHealthInput{
+		TargetGroupArn: awssdk.String(targetGroupArn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe target health: %v", err)
+	}
+
+	var descriptions []TargetHealthDescription
+	for _, d := range output.TargetHealthDescriptions {
+		var description TargetHealthDescription
+		if d.Target != nil {
+			description.Target = Target{ID: awssdk.ToString(d.Target.Id), Port: int(awssdk.ToInt32(d.Target.Port))}
+		}
+		if d.TargetHealth != nil {
+			description.TargetHealth = TargetHealth{
+				State:       string(d.TargetHealth.State),
+				Reason:      string(d.TargetHealth.Reason),
+				Description: awssdk.ToString(d.TargetHealth.Description),
+			}
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions, nil
+}
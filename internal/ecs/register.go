@@ -0,0 +1,83 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// Fetches a task definition and its tags as a register-task-definition request, so a modified copy can be
+// registered as a new revision without losing fields infra does not model
+func GetTaskDefinitionInput(taskDefinition, profile, region string) (*ecssdk.RegisterTaskDefinitionInput, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.DescribeTaskDefinition(context.TODO(), &ecssdk.DescribeTaskDefinitionInput{
+		TaskDefinition: awssdk.String(taskDefinition),
+		Include:        []ecstypes.TaskDefinitionField{ecstypes.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ECS task definition: %v", err)
+	}
+
+	// Read-only fields such as the ARN, revision and status are left behind
+	td := output.TaskDefinition
+	input := &ecssdk.RegisterTaskDefinitionInput{
+		Family:                  td.Family,
+		ContainerDefinitions:    td.ContainerDefinitions,
+		Cpu:                     td.Cpu,
+		Memory:                  td.Memory,
+		EphemeralStorage:        td.EphemeralStorage,
+		ExecutionRoleArn:        td.ExecutionRoleArn,
+		TaskRoleArn:             td.TaskRoleArn,
+		InferenceAccelerators:   td.InferenceAccelerators,
+		IpcMode:                 td.IpcMode,
+		PidMode:                 td.PidMode,
+		NetworkMode:             td.NetworkMode,
+		PlacementConstraints:    td.PlacementConstraints,
+		ProxyConfiguration:      td.ProxyConfiguration,
+		RequiresCompatibilities: td.RequiresCompatibilities,
+		RuntimePlatform:         td.RuntimePlatform,
+		Volumes:                 td.Volumes,
+	}
+	if len(output.Tags) > 0 {
+		input.Tags = output.Tags
+	}
+	return input, nil
+}
+
+// Replaces the image of named containers in a register-task-definition request
+func SetContainerImages(input *ecssdk.RegisterTaskDefinitionInput, images map[string]string) error {
+	found := map[string]bool{}
+	for i := range input.ContainerDefinitions {
+		definition := &input.ContainerDefinitions[i]
+		name := awssdk.ToString(definition.Name)
+		if image, ok := images[name]; ok {
+			definition.Image = awssdk.String(image)
+			found[name] = true
+		}
+	}
+	for name := range images {
+		if !found[name] {
+			return fmt.Errorf("container %s is not in the task definition", name)
+		}
+	}
+	return nil
+}
+
+// Registers a new task definition revision and returns it
+func RegisterTaskDefinition(input *ecssdk.RegisterTaskDefinitionInput, profile, region string) (*TaskDefinition, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.RegisterTaskDefinition(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register ECS task definition: %v", err)
+	}
+	return taskDefinitionFromSDK(output.TaskDefinition), nil
+}
@@ -0,0 +1,116 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// RunTaskInput is the run-task request for a one-off task
+type RunTaskInput struct {
+	Cluster                  string
+	TaskDefinition           string
+	LaunchType               string
+	CapacityProviderStrategy []CapacityProviderStrategyItem
+	PlatformVersion          string
+	NetworkConfiguration     *NetworkConfiguration
+	EnableExecuteCommand     bool
+	StartedBy                string
+	Overrides                RunTaskOverrides
+}
+
+// RunTaskOverrides are the container overrides a one-off task is started with
+type RunTaskOverrides struct {
+	ContainerOverrides []ContainerOverride
+}
+
+// ContainerOverride replaces the command or adds environment variables to a container in a task
+type ContainerOverride struct {
+	Name        string
+	Command     []string
+	Environment []KeyValue
+}
+
+// Builds a run-task request that starts a one-off task with a service's task definition and network settings,
+// overriding the command of one of its containers
+func RunTaskInputFromService(cluster string, svc *Service, containerName string, command []string) *RunTaskInput {
+	input := &RunTaskInput{
+		Cluster:                  cluster,
+		TaskDefinition:           svc.TaskDefinition,
+		LaunchType:               svc.LaunchType,
+		CapacityProviderStrategy: svc.CapacityProviderStrategy,
+		PlatformVersion:          svc.PlatformVersion,
+		EnableExecuteCommand:     svc.EnableExecuteCommand,
+		StartedBy:                "infra-run",
+		Overrides: RunTaskOverrides{
+			ContainerOverrides: []ContainerOverride{{Name: containerName, Command: command}},
+		},
+	}
+	if len(svc.NetworkConfiguration.AwsvpcConfiguration.Subnets) > 0 {
+		network := svc.NetworkConfiguration
+		input.NetworkConfiguration = &network
+	}
+	return input
+}
+
+// Starts a one-off ECS task and returns it
+func RunTask(input *RunTaskInput, profile, region string) (*Task, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.RunTask(context.TODO(), input.sdk())
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ECS task: %v", err)
+	}
+	if len(output.Failures) > 0 {
+		f := output.Failures[0]
+		return nil, fmt.Errorf("failed to run ECS task: %s %s", awssdk.ToString(f.Reason), awssdk.ToString(f.Detail))
+	}
+	if len(output.Tasks) == 0 {
+		return nil, fmt.Errorf("failed to run ECS task: no task was started")
+	}
+	return taskFromSDK(output.Tasks[0]), nil
+}
+
+// Converts the request to its SDK form, leaving unset fields out
+func (input *RunTaskInput) sdk() *ecssdk.RunTaskInput {
+	request := &ecssdk.RunTaskInput{
+		Cluster:              awssdk.String(input.Cluster),
+		TaskDefinition:       awssdk.String(input.TaskDefinition),
+		LaunchType:           ecstypes.LaunchType(input.LaunchType),
+		EnableExecuteCommand: input.EnableExecuteCommand,
+		Overrides:            &ecstypes.TaskOverride{},
+	}
+	if input.PlatformVersion != "" {
+		request.PlatformVersion = awssdk.String(input.PlatformVersion)
+	}
+	if input.StartedBy != "" {
+		request.StartedBy = awssdk.String(input.StartedBy)
+	}
+	for _, item := range input.CapacityProviderStrategy {
+		request.CapacityProviderStrategy = append(request.CapacityProviderStrategy, ecstypes.CapacityProviderStrategyItem{
+			CapacityProvider: awssdk.String(item.CapacityProvider),
+			Weight:           int32(item.Weight),
+			Base:             int32(item.Base),
+		})
+	}
+	if network := input.NetworkConfiguration; network != nil {
+		request.NetworkConfiguration = &ecstypes.NetworkConfiguration{AwsvpcConfiguration: &ecstypes.AwsVpcConfiguration{
+			Subnets:        network.AwsvpcConfiguration.Subnets,
+			SecurityGroups: network.AwsvpcConfiguration.SecurityGroups,
+			AssignPublicIp: ecstypes.AssignPublicIp(network.AwsvpcConfiguration.AssignPublicIP),
+		}}
+	}
+	for _, o := range input.Overrides.ContainerOverrides {
+		override := ecstypes.ContainerOverride{Name: awssdk.String(o.Name), Command: o.Command}
+		for _, env := range o.Environment {
+			override.Environment = append(override.Environment, ecstypes.KeyValuePair{Name: awssdk.String(env.Name), Value: awssdk.String(env.Value)})
+		}
+		request.Overrides.ContainerOverrides = append(request.Overrides.ContainerOverrides, override)
+	}
+	return request
+}
@@ -0,0 +1,62 @@
+package ecs
+
+import (
+	"fmt"
+	"strconv"
+
+	"raid/infra/internal/utils"
+)
+
+// Returns family:revision of the task definition
+func (td *TaskDefinition) Name() string {
+	return fmt.Sprintf("%s:%d", td.Family, td.Revision)
+}
+
+// Flattens the settings compared by `infra ecs taskdef diff` into key/value pairs: task-level
+// CPU/memory and IAM roles, and each container's image, CPU/memory, environment, secret ARNs
+// and port mappings
+func (td *TaskDefinition) Fields() map[string]string {
+	fields := map[string]string{}
+	scalars := map[string]string{
+		"cpu":              td.Cpu,
+		"memory":           td.Memory,
+		"networkMode":      td.NetworkMode,
+		"taskRoleArn":      td.TaskRoleArn,
+		"executionRoleArn": td.ExecutionRoleArn,
+	}
+	// Unset scalar fields are left out; environment entries keep empty values
+	for key, value := range scalars {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	for _, c := range td.ContainerDefinitions {
+		prefix := "container " + c.Name + " "
+		if c.Image != "" {
+			fields[prefix+"image"] = c.Image
+		}
+		fields[prefix+"essential"] = strconv.FormatBool(c.IsEssential())
+		if c.Cpu != 0 {
+			fields[prefix+"cpu"] = strconv.Itoa(c.Cpu)
+		}
+		if c.Memory != nil {
+			fields[prefix+"memory"] = strconv.Itoa(*c.Memory)
+		}
+		if c.MemoryReservation != nil {
+			fields[prefix+"memoryReservation"] = strconv.Itoa(*c.MemoryReservation)
+		}
+		for _, env := range c.Environment {
+			fields[prefix+"env "+env.Name] = env.Value
+		}
+		for _, file := range c.EnvironmentFiles {
+			fields[prefix+"environmentFile "+file.Value] = file.Type
+		}
+		for _, secret := range c.Secrets {
+			fields[prefix+"secret "+secret.Name] = secret.ValueFrom
+		}
+		for _, pm := range c.PortMappings {
+			fields[fmt.Sprintf("%sport %d/%s", prefix, pm.ContainerPort, pm.Protocol)] = utils.ValueOrDash(pm.Name)
+		}
+	}
+	return fields
+}
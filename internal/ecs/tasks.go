@@ -0,0 +1,138 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ecssdk "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"raid/infra/internal/utils"
+)
+
+// Scopes offered when selecting ECS tasks
+const (
+	TaskScopeService = "Service"
+	TaskScopeFamily  = "Task family"
+	TaskScopeAll     = "All tasks"
+)
+
+// TaskScope narrows the tasks listed in a cluster to a service or a task definition family
+type TaskScope struct {
+	Service string
+	Family  string
+}
+
+// Returns a description of the scope for display
+func (s TaskScope) String() string {
+	switch {
+	case s.Service != "":
+		return "service " + s.Service
+	case s.Family != "":
+		return "task family " + s.Family
+	default:
+		return "all tasks"
+	}
+}
+
+// Fetches the active task definition families
+func GetTaskDefinitionFamilies(profile, region string) ([]string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var families []string
+	paginator := ecssdk.NewListTaskDefinitionFamiliesPaginator(client, &ecssdk.ListTaskDefinitionFamiliesInput{
+		Status: ecstypes.TaskDefinitionFamilyStatusActive,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ECS task definition families: %v", err)
+		}
+		families = append(families, page.Families...)
+	}
+	if len(families) == 0 {
+		return nil, fmt.Errorf("no ECS task definition families available")
+	}
+	return families, nil
+}
+
+// Prompts the user to choose whether to list the tasks of a service, a task family, or the whole cluster
+func SelectECSTaskScope(cluster, profile, region string) (TaskScope, error) {
+	scope, err := utils.PromptSelection([]string{TaskScopeService, TaskScopeFamily, TaskScopeAll}, "Task Scope")
+	if err != nil {
+		return TaskScope{}, err
+	}
+
+	switch scope {
+	case TaskScopeService:
+		service, err := SelectECSService(cluster, profile, region)
+		if err != nil {
+			return TaskScope{}, err
+		}
+		return TaskScope{Service: service}, nil
+	case TaskScopeFamily:
+		families, err := GetTaskDefinitionFamilies(profile, region)
+		if err != nil {
+			return TaskScope{}, err
+		}
+		family, err := utils.PromptSelection(families, "Task Family")
+		if err != nil {
+			return TaskScope{}, err
+		}
+		return TaskScope{Family: family}, nil
+	default:
+		return TaskScope{}, nil
+	}
+}
+
+// Fetches the IDs of the tasks in a scope with the given desired status (RUNNING or STOPPED).
+// ECS only returns stopped tasks for about an hour after they stop.
+func GetScopedECSTasks(cluster string, scope TaskScope, desiredStatus, profile, region string) ([]string, error) {
+	input := &ecssdk.ListTasksInput{
+		Cluster:       awssdk.String(cluster),
+		DesiredStatus: ecstypes.DesiredStatus(desiredStatus),
+	}
+	switch {
+	case scope.Service != "":
+		input.ServiceName = awssdk.String(scope.Service)
+	case scope.Family != "":
+		input.Family = awssdk.String(scope.Family)
+	}
+
+	taskIDs, err := listTaskIDs(profile, region, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECS tasks: %v", err)
+	}
+	return taskIDs, nil
+}
+
+// Prompts the user to select a running ECS task from a scope, labelled with its task definition and service
+func SelectScopedECSTask(cluster string, scope TaskScope, profile, region string) (*Task, error) {
+	taskIDs, err := GetScopedECSTasks(cluster, scope, "RUNNING", profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(taskIDs) == 0 {
+		return nil, fmt.Errorf("no running ECS tasks in %s", scope)
+	}
+	tasks, err := DescribeECSTasks(cluster, taskIDs, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]string, len(tasks))
+	byOption := map[string]*Task{}
+	for i := range tasks {
+		task := &tasks[i]
+		options[i] = fmt.Sprintf("%s (%s, %s)", task.ID(), task.TaskDefinitionName(), task.Group)
+		byOption[options[i]] = task
+	}
+	selection, err := utils.PromptSelection(options, "ECS Task")
+	if err != nil {
+		return nil, err
+	}
+	return byOption[selection], nil
+}
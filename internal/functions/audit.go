@@ -0,0 +1,114 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"raid/infra/internal/audit"
+	"raid/infra/internal/aws"
+	"raid/infra/internal/utils"
+)
+
+// Prints the audited sessions started within the given window, optionally filtered by kind. Sessions
+// with no end record, still running or killed before they could end, are shown as unfinished.
+func ListAuditSessions(since time.Duration, kind string) error {
+	sessions, err := audit.Load()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Time{}
+	if since > 0 {
+		cutoff = time.Now().Add(-since)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tDURATION\tKIND\tCALLER\tPROFILE\tTARGET\tDB ENDPOINT\tLOCAL PORT\tEXIT")
+	count := 0
+	for _, s := range sessions {
+		if s.StartTime.Before(cutoff) || (kind != "" && s.Kind != kind) {
+			continue
+		}
+		duration, exit := s.EndTime.Sub(s.StartTime).Round(time.Second).String(), fmt.Sprintf("%d", s.ExitStatus)
+		if s.Status == audit.StatusStarted {
+			duration, exit = "-", "unfinished"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.StartTime.Local().Format("2006-01-02 15:04:05"),
+			duration,
+			s.Kind,
+			s.CallerARN,
+			s.Profile,
+			auditTarget(s),
+			utils.ValueOrDash(s.DBEndpoint),
+			portOrDash(s.LocalPort),
+			exit)
+		count++
+	}
+	if count == 0 {
+		fmt.Println("No audited sessions found.")
+		return nil
+	}
+	return w.Flush()
+}
+
+// Uploads the local audit log to s3://bucket/prefix/<hostname>/audit-<timestamp>.jsonl
+func ShipAuditLog(bucket, prefix string) error {
+	if bucket == "" {
+		return fmt.Errorf("no audit bucket configured (use --bucket or set INFRA_AUDIT_BUCKET)")
+	}
+
+	logPath, err := audit.LogPath()
+	if err != nil {
+		return err
+	}
+	body, err := os.ReadFile(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no audit log found at %s", logPath)
+		}
+		return fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown-host"
+	}
+	key := path.Join(strings.Trim(prefix, "/"), hostname, fmt.Sprintf("audit-%s.jsonl", time.Now().UTC().Format("20060102T150405Z")))
+
+	if err := aws.UploadObject(selectedProfile, selectedRegion, bucket, key, body); err != nil {
+		return err
+	}
+	fmt.Printf("Audit log shipped to s3://%s/%s\n", bucket, key)
+	return nil
+}
+
+func auditTarget(s audit.Session) string {
+	if s.Instance != "" {
+		return s.Instance
+	}
+	target := s.Cluster + "/" + s.Task
+	if s.Container != "" {
+		target += "/" + s.Container
+	}
+	if s.RemotePort != 0 {
+		target += fmt.Sprintf(":%d", s.RemotePort)
+	}
+	return target
+}
+
+func portOrDash(port int) string {
+	if port == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", port)
+}
@@ -0,0 +1,410 @@
+package functions
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"raid/infra/internal/audit"
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ec2"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// dbTunnel is a background SSM tunnel to a database, audited for as long as it is open
+type dbTunnel struct {
+	*utils.Tunnel
+	session *audit.Session
+}
+
+// Prompts for an EC2 instance or ECS container to tunnel through, as `infra portforward` does,
+// and opens a background tunnel from a free local port to the database
+func openDBTunnel(kind, command, profile, region string, conn *rds.DBConnection) (*dbTunnel, error) {
+	selection, err := utils.PromptSelection([]string{"EC2", "ECS"}, "Tunnel Host")
+	if err != nil {
+		return nil, err
+	}
+
+	s := audit.Session{
+		Kind:       kind,
+		Profile:    profile,
+		Region:     region,
+		Command:    command,
+		DBEndpoint: fmt.Sprintf("%s:%d", conn.Host, conn.Port),
+	}
+	var target string
+	if selection == "EC2" {
+		instanceID, err := ec2.SelectEC2Instance(profile, region)
+		if err != nil {
+			return nil, err
+		}
+		target, s.Instance = instanceID, instanceID
+	} else {
+		cluster, err := ecs.SelectECSCluster(profile, region)
+		if err != nil {
+			return nil, err
+		}
+		scope, err := ecs.SelectECSTaskScope(cluster, profile, region)
+		if err != nil {
+			return nil, err
+		}
+		task, err := ecs.SelectScopedECSTask(cluster, scope, profile, region)
+		if err != nil {
+			return nil, err
+		}
+		container, err := ecs.SelectECSContainer(cluster, task.ID(), profile, region)
+		if err != nil {
+			return nil, err
+		}
+		target, err = ecs.ContainerSSMTarget(cluster, task.ID(), container)
+		if err != nil {
+			return nil, err
+		}
+		s.Cluster, s.Task, s.Container = cluster, task.ID(), container.Name
+	}
+
+	fmt.Printf("Opening a tunnel to %s:%d through %s\n", conn.Host, conn.Port, target)
+	session := audit.Start(s)
+	tunnel, err := utils.StartTunnel(target, conn.Host, conn.Port, profile, region)
+	if err != nil {
+		session.End(err)
+		return nil, err
+	}
+	session.LocalPort = tunnel.LocalPort
+	fmt.Printf("Tunnel open on 127.0.0.1:%d\n", tunnel.LocalPort)
+	return &dbTunnel{Tunnel: tunnel, session: session}, nil
+}
+
+// Closes the tunnel and records the session with the result of the work done through it
+func (t *dbTunnel) close(err error) {
+	t.Tunnel.Close()
+	t.session.End(err)
+	fmt.Println("Tunnel closed.")
+}
+
+// Selects a database and resolves how to connect to it and which credentials to use. Without
+// a user or secret, the master user is used, with the secret RDS manages its password in if any.
+// An empty password leaves it to the client to prompt for one or read it from its environment.
+func selectDBConnection(user, secret, profile, region string) (*rds.DBConnection, string, string, error) {
+	target, err := rds.SelectDBTarget(profile, region)
+	if err != nil {
+		return nil, "", "", err
+	}
+	conn, err := rds.GetDBConnection(target, profile, region)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if rds.EngineFamily(conn.Engine) == "" {
+		return nil, "", "", fmt.Errorf("%s runs %s; only PostgreSQL and MySQL/MariaDB engines are supported", target, conn.Engine)
+	}
+
+	if secret == "" && user == "" && conn.MasterUserSecretArn != "" {
+		secret = conn.MasterUserSecretArn
+		fmt.Println("Using the master user secret managed by RDS")
+	}
+	password := ""
+	if secret != "" {
+		secretUser, secretPassword, err := aws.GetDatabaseCredentials(profile, region, secret)
+		if err != nil {
+			return nil, "", "", err
+		}
+		password = secretPassword
+		if user == "" {
+			user = secretUser
+		}
+	}
+	if user == "" {
+		user = conn.MasterUsername
+	}
+	return conn, user, password, nil
+}
+
+// Returns the command for a PostgreSQL or MySQL client tool connecting to the tunnel, and a function
+// removing any credentials file it needs. The password is passed through the environment for
+// PostgreSQL and an option file for MySQL, so it never appears in the process list.
+func dbClientCommand(family, tool string, port int, user, password string, args ...string) (*exec.Cmd, func(), error) {
+	cleanup := func() {}
+
+	if family == "postgres" {
+		cmd := exec.Command(tool, append([]string{"-h", "127.0.0.1", "-p", strconv.Itoa(port), "-U", user}, args...)...)
+		cmd.Env = os.Environ()
+		if password != "" {
+			cmd.Env = append(cmd.Env, "PGPASSWORD="+password)
+		}
+		return cmd, cleanup, nil
+	}
+
+	var connArgs []string
+	if password != "" {
+		f, err := os.CreateTemp("", "infra-mysql-*.cnf")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create MySQL option file: %v", err)
+		}
+		cleanup = func() { os.Remove(f.Name()) }
+		_, err = fmt.Fprintf(f, "[client]\npassword=\"%s\"\n", strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(password))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to write MySQL option file: %v", err)
+		}
+		// The option file must be the first argument
+		connArgs = append(connArgs, "--defaults-extra-file="+f.Name())
+	}
+	connArgs = append(connArgs, "--protocol=TCP", "-h", "127.0.0.1", "-P", strconv.Itoa(port), "-u", user)
+	if password == "" && os.Getenv("MYSQL_PWD") == "" {
+		connArgs = append(connArgs, "-p")
+	}
+	return exec.Command(tool, append(connArgs, args...)...), cleanup, nil
+}
+
+// Dumps a database through an SSM tunnel with pg_dump or mysqldump and streams it, gzip
+// compressed, to <s3URI>/<identifier>/<database>-<yyyymmdd-hhmmss>.sql.gz with SSE-KMS
+func ExecuteDBDump(database, user, secret, s3URI, kmsKeyID string) error {
+	if s3URI == "" {
+		return fmt.Errorf("--s3 is required")
+	}
+	bucket, prefix, err := aws.ParseS3URI(s3URI)
+	if err != nil {
+		return err
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the database and credentials
+	conn, user, password, err := selectDBConnection(user, secret, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if database == "" {
+		database, err = utils.PromptInput("Database name", func(input string) error {
+			if input == "" {
+				return fmt.Errorf("database name is required")
+			}
+			return nil
+		}, "")
+		if err != nil {
+			return err
+		}
+	}
+	family := rds.EngineFamily(conn.Engine)
+	tool, dumpArgs := "pg_dump", []string{"-d", database, "--no-owner", "--no-privileges"}
+	if family == "mysql" {
+		tool, dumpArgs = "mysqldump", []string{"--single-transaction", "--quick", "--routines", "--triggers", "--no-tablespaces", database}
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s was not found in PATH; install the %s client tools", tool, family)
+	}
+	key := path.Join(prefix, conn.Identifier, fmt.Sprintf("%s-%s.sql.gz", database, time.Now().UTC().Format("20060102-150405")))
+
+	// Step 3: Open the tunnel
+	tunnel, err := openDBTunnel(audit.KindDBDump, tool+" "+database, selectedProfile, selectedRegion, conn)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Dump through it, then close it
+	err = dumpToS3(tunnel.LocalPort, family, tool, user, password, dumpArgs, selectedProfile, selectedRegion, bucket, key, kmsKeyID)
+	tunnel.close(err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Dumped %s on %s to s3://%s/%s\n", database, conn.Identifier, bucket, key)
+	return nil
+}
+
+// Runs the dump and uploads its compressed output as it is produced
+func dumpToS3(port int, family, tool, user, password string, args []string, profile, region, bucket, key, kmsKeyID string) error {
+	cmd, cleanup, err := dbClientCommand(family, tool, port, user, password, args...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	reader, writer := io.Pipe()
+	compressor := gzip.NewWriter(writer)
+	cmd.Stdout = compressor
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %v", tool, err)
+	}
+	dumped := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		if err == nil {
+			err = compressor.Close()
+		}
+		if err != nil {
+			err = fmt.Errorf("%s failed: %v", tool, err)
+		}
+		writer.CloseWithError(err)
+		dumped <- err
+	}()
+
+	progress := utils.NewProgress("s3://"+bucket+"/"+key, 0)
+	uploadErr := aws.UploadStream(profile, region, bucket, key, kmsKeyID, progress.Reader(reader))
+	progress.Finish()
+	// Stops the dump if the upload failed part way. A failed dump fails the upload too, with the
+	// dump's error, so the upload's error is the cause either way.
+	reader.CloseWithError(fmt.Errorf("upload stopped"))
+	dumpErr := <-dumped
+	if uploadErr != nil {
+		return uploadErr
+	}
+	return dumpErr
+}
+
+// Restores a gzip compressed SQL dump from S3 into a database through an SSM tunnel with psql or mysql,
+// after confirmation. If s3URI is a prefix ending in /, the dump is selected from the .sql.gz objects under it.
+func ExecuteDBRestore(s3URI, database, user, secret string, autoApprove bool) error {
+	if s3URI == "" {
+		return fmt.Errorf("--from is required")
+	}
+	bucket, key, err := aws.ParseS3URI(s3URI)
+	if err != nil {
+		return err
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the dump
+	if key == "" || strings.HasSuffix(key, "/") {
+		key, err = selectDump(bucket, key, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Step 3: Select the database and credentials
+	conn, user, password, err := selectDBConnection(user, secret, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if database == "" {
+		// Dumps are named <database>-<yyyymmdd-hhmmss>.sql.gz
+		name := strings.TrimSuffix(path.Base(key), ".sql.gz")
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if j := strings.LastIndex(name[:i], "-"); j > 0 {
+				name = name[:j]
+			}
+		}
+		database, err = utils.PromptInput("Database name", func(input string) error {
+			if input == "" {
+				return fmt.Errorf("database name is required")
+			}
+			return nil
+		}, name)
+		if err != nil {
+			return err
+		}
+	}
+	family := rds.EngineFamily(conn.Engine)
+	tool, restoreArgs := "psql", []string{"-d", database, "--set", "ON_ERROR_STOP=1", "--quiet", "--single-transaction"}
+	if family == "mysql" {
+		tool, restoreArgs = "mysql", []string{database}
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s was not found in PATH; install the %s client tools", tool, family)
+	}
+
+	if !autoApprove && !utils.ConfirmPrompt(fmt.Sprintf("Restore s3://%s/%s into database %s on %s? (Y/N)", bucket, key, database, conn.Identifier)) {
+		return fmt.Errorf("restore cancelled")
+	}
+
+	// Step 4: Open the tunnel and stream the dump into the client
+	tunnel, err := openDBTunnel(audit.KindDBRestore, tool+" "+database, selectedProfile, selectedRegion, conn)
+	if err != nil {
+		return err
+	}
+	err = restoreFromS3(tunnel.LocalPort, family, tool, user, password, restoreArgs, selectedProfile, selectedRegion, bucket, key)
+	tunnel.close(err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored s3://%s/%s into %s on %s\n", bucket, key, database, conn.Identifier)
+	return nil
+}
+
+// Downloads, decompresses and pipes a dump into the client as it is downloaded
+func restoreFromS3(port int, family, tool, user, password string, args []string, profile, region, bucket, key string) error {
+	body, size, err := aws.OpenObject(profile, region, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	progress := utils.NewProgress("s3://"+bucket+"/"+key, size)
+	decompressor, err := gzip.NewReader(progress.Reader(body))
+	if err != nil {
+		return fmt.Errorf("s3://%s/%s is not gzip compressed: %v", bucket, key, err)
+	}
+
+	cmd, cleanup, err := dbClientCommand(family, tool, port, user, password, args...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	cmd.Stdin = decompressor
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	progress.Finish()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v", tool, err)
+	}
+	return nil
+}
+
+// Prompts the user to select one of the dumps under a prefix, newest first
+func selectDump(bucket, prefix, profile, region string) (string, error) {
+	objects, err := aws.ListObjects(profile, region, bucket, prefix)
+	if err != nil {
+		return "", err
+	}
+	var dumps []aws.S3Object
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, ".sql.gz") {
+			dumps = append(dumps, object)
+		}
+	}
+	if len(dumps) == 0 {
+		return "", fmt.Errorf("no .sql.gz dumps under s3://%s/%s", bucket, prefix)
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].LastModified.After(dumps[j].LastModified) })
+
+	options := make([]string, len(dumps))
+	for i, d := range dumps {
+		options[i] = fmt.Sprintf("%s (%s, %s)", d.Key, d.LastModified.Local().Format("2006-01-02 15:04"), utils.FormatBytes(d.Size))
+	}
+	selection, err := utils.PromptSelection(options, "Dump")
+	if err != nil {
+		return "", err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return dumps[i].Key, nil
+		}
+	}
+	return "", fmt.Errorf("invalid dump selection: %s", selection)
+}
@@ -0,0 +1,294 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// Runs SQL against an Aurora cluster through the RDS Data API, with no tunnel. The SQL is a
+// statement, or a file of statements separated by semicolons. With batchFile, the statement is
+// run once for each set of named parameters in the JSON array it holds. With transaction, all
+// statements run in one transaction that is rolled back if any fails.
+func ExecuteDBQuery(sql, file, batchFile, database, secret, format string, transaction bool) error {
+	if err := utils.ValidateOutputFormat(format); err != nil {
+		return err
+	}
+	if (sql == "") == (file == "") {
+		return fmt.Errorf("exactly one of --sql and --file is required")
+	}
+	if batchFile != "" && sql == "" {
+		return fmt.Errorf("--batch needs the statement to run as --sql")
+	}
+
+	// Step 1: Read the script and parameter sets
+	var script []byte
+	if file != "" {
+		var err error
+		script, err = os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", file, err)
+		}
+	}
+	var parameterSets []map[string]interface{}
+	if batchFile != "" {
+		data, err := os.ReadFile(batchFile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", batchFile, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&parameterSets); err != nil {
+			return fmt.Errorf("%s must be a JSON array of objects of parameters: %v", batchFile, err)
+		}
+	}
+
+	// Step 2: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select the cluster and the secret to authenticate with
+	cluster, err := rds.SelectDataAPICluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		secret, err = selectDBSecret(cluster, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Step 4: Split the script into statements by the rules of the cluster's engine
+	statements := []string{sql}
+	if file != "" {
+		statements = splitSQL(string(script), strings.Contains(cluster.Engine, "mysql"))
+		if len(statements) == 0 {
+			return fmt.Errorf("%s contains no statements", file)
+		}
+	}
+	api, err := aws.NewDataAPI(selectedProfile, selectedRegion, cluster.DBClusterArn, secret, database)
+	if err != nil {
+		return err
+	}
+
+	// Step 5: Run the statements, in a transaction if asked to
+	transactionID := ""
+	if transaction {
+		transactionID, err = api.BeginTransaction()
+		if err != nil {
+			return err
+		}
+	}
+	if err := runDataAPIStatements(api, statements, parameterSets, transactionID, format); err != nil {
+		if transactionID != "" {
+			if rollbackErr := api.RollbackTransaction(transactionID); rollbackErr != nil {
+				return fmt.Errorf("%v; %v", err, rollbackErr)
+			}
+			fmt.Fprintln(os.Stderr, "Transaction rolled back.")
+		}
+		return err
+	}
+	if transactionID != "" {
+		if err := api.CommitTransaction(transactionID); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Transaction committed.")
+	}
+	return nil
+}
+
+// Runs each statement and writes its result set, or the number of records it updated
+func runDataAPIStatements(api *aws.DataAPI, statements []string, parameterSets []map[string]interface{}, transactionID, format string) error {
+	if parameterSets != nil {
+		count, err := api.BatchExecute(statements[0], parameterSets, transactionID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Ran the statement for %d parameter set(s).\n", count)
+		return nil
+	}
+
+	for i, statement := range statements {
+		if len(statements) > 1 {
+			fmt.Fprintf(os.Stderr, "-- [%d/%d] %s\n", i+1, len(statements), firstLine(statement))
+		}
+		result, err := api.Execute(statement, transactionID)
+		if err != nil {
+			return err
+		}
+		if len(result.Columns) == 0 {
+			fmt.Fprintf(os.Stderr, "%d record(s) updated.\n", result.RecordsUpdated)
+			continue
+		}
+
+		rows := make([][]string, len(result.Rows))
+		records := make([]map[string]interface{}, len(result.Rows))
+		for r, row := range result.Rows {
+			rows[r] = make([]string, len(row))
+			records[r] = map[string]interface{}{}
+			for c, value := range row {
+				rows[r][c] = formatSQLValue(value)
+				records[r][result.Columns[c]] = value
+			}
+		}
+		if err := utils.WriteRecords(os.Stdout, format, result.Columns, rows, records); err != nil {
+			return err
+		}
+		if format == utils.OutputTable {
+			fmt.Fprintf(os.Stderr, "(%d row(s))\n", len(rows))
+		}
+	}
+	return nil
+}
+
+// Prompts the user to select the secret to authenticate with, offering the master user secret
+// RDS manages for the cluster first
+func selectDBSecret(cluster *rds.DBCluster, profile, region string) (string, error) {
+	secrets, err := aws.ListSecrets(profile, region)
+	if err != nil {
+		return "", err
+	}
+
+	var options, arns []string
+	if cluster.MasterUserSecret != nil {
+		options = append(options, fmt.Sprintf("[RDS managed master user] %s", cluster.MasterUserSecret.SecretArn))
+		arns = append(arns, cluster.MasterUserSecret.SecretArn)
+	}
+	for _, s := range secrets {
+		if cluster.MasterUserSecret != nil && s.ARN == cluster.MasterUserSecret.SecretArn {
+			continue
+		}
+		option := s.Name
+		if s.Description != "" {
+			option = fmt.Sprintf("%s (%s)", s.Name, s.Description)
+		}
+		options = append(options, option)
+		arns = append(arns, s.ARN)
+	}
+	if len(options) == 0 {
+		return "", fmt.Errorf("no Secrets Manager secrets found to authenticate with")
+	}
+
+	selection, err := utils.PromptSelection(options, "Secret")
+	if err != nil {
+		return "", err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return arns[i], nil
+		}
+	}
+	return "", fmt.Errorf("invalid secret selection: %s", selection)
+}
+
+// Formats a result value for a table or CSV cell
+func formatSQLValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return v
+	case []string, []int64, []float64, []bool, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// Returns the first line of a statement, for progress output
+func firstLine(statement string) string {
+	line, _, _ := strings.Cut(statement, "\n")
+	return line
+}
+
+// Splits a SQL script into statements at semicolons, ignoring those in quotes, comments and
+// PostgreSQL dollar-quoted bodies. Empty statements are dropped. backslashEscapes is set for
+// MySQL, where a backslash escapes the next character in a quoted string.
+func splitSQL(script string, backslashEscapes bool) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == ';':
+			flush()
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// Quoted string or identifier; doubled quotes are read as two adjacent strings. With
+			// backslashEscapes (MySQL), a backslash in a string escapes the character after it.
+			end := i + 1
+			for end < len(script) && script[end] != c {
+				if backslashEscapes && c != '`' && script[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(script) {
+				current.WriteString(script[i:])
+				i = len(script)
+				continue
+			}
+			current.WriteString(script[i : end+1])
+			i = end
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+				continue
+			}
+			i += end + 3
+			continue
+		case c == '$':
+			// $$ or $tag$ opens a dollar-quoted body that runs to the same tag
+			if tagEnd := strings.IndexByte(script[i+1:], '$'); tagEnd >= 0 && isDollarTag(script[i+1:i+1+tagEnd]) {
+				tag := script[i : i+tagEnd+2]
+				end := strings.Index(script[i+len(tag):], tag)
+				if end < 0 {
+					end = len(script) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				current.WriteString(script[i : i+len(tag)+end])
+				i += len(tag) + end - 1
+				continue
+			}
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements
+}
+
+// Reports whether s can be the tag of a dollar quote ($s$)
+func isDollarTag(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package functions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQL(t *testing.T) {
+	tests := []struct {
+		name            string
+		script          string
+		backslashEscape bool
+		want            []string
+	}{
+		{
+			name:   "semicolons",
+			script: "select 1; select 2;\n select 3",
+			want:   []string{"select 1", "select 2", "select 3"},
+		},
+		{
+			name:   "empty statements",
+			script: " ;; select 1;\n;\n",
+			want:   []string{"select 1"},
+		},
+		{
+			name:   "single quotes",
+			script: "insert into t values ('a;b'); select 2",
+			want:   []string{"insert into t values ('a;b')", "select 2"},
+		},
+		{
+			name:   "doubled quotes",
+			script: "select 'it''s; fine'; select 2",
+			want:   []string{"select 'it''s; fine'", "select 2"},
+		},
+		{
+			name:   "double quoted identifier",
+			script: `select "a;b" from t; select 2`,
+			want:   []string{`select "a;b" from t`, "select 2"},
+		},
+		{
+			name:   "backtick identifier",
+			script: "select `a;b` from t; select 2",
+			want:   []string{"select `a;b` from t", "select 2"},
+		},
+		{
+			name:            "mysql backslash escape",
+			script:          `select 'it\'s; fine'; select 2`,
+			backslashEscape: true,
+			want:            []string{`select 'it\'s; fine'`, "select 2"},
+		},
+		{
+			name:            "mysql escaped backslash before quote",
+			script:          `select 'a\\'; select 2`,
+			backslashEscape: true,
+			want:            []string{`select 'a\\'`, "select 2"},
+		},
+		{
+			name:   "postgresql backslash is literal",
+			script: `select 'a\'; select 2`,
+			want:   []string{`select 'a\'`, "select 2"},
+		},
+		{
+			name:            "backslash in backticks is literal",
+			script:          "select `a\\`; select 2",
+			backslashEscape: true,
+			want:            []string{"select `a\\`", "select 2"},
+		},
+		{
+			name:   "unterminated quote",
+			script: "select 'a; select 2",
+			want:   []string{"select 'a; select 2"},
+		},
+		{
+			name:   "line comment",
+			script: "select 1 -- not; split\n; select 2",
+			want:   []string{"select 1", "select 2"},
+		},
+		{
+			name:   "line comment at end",
+			script: "select 1; -- done; really",
+			want:   []string{"select 1"},
+		},
+		{
+			name:   "block comment",
+			script: "select /* a; b */ 1; select 2",
+			want:   []string{"select  1", "select 2"},
+		},
+		{
+			name:   "unterminated block comment",
+			script: "select 1; /* a; b",
+			want:   []string{"select 1"},
+		},
+		{
+			name:   "dollar quote",
+			script: "create function f() returns int as $$ select 1; $$ language sql; select 2",
+			want:   []string{"create function f() returns int as $$ select 1; $$ language sql", "select 2"},
+		},
+		{
+			name:   "tagged dollar quote",
+			script: "do $body$ begin perform 1; end $body$; select 2",
+			want:   []string{"do $body$ begin perform 1; end $body$", "select 2"},
+		},
+		{
+			name:   "positional parameter is not a dollar quote",
+			script: "select $1; select $2",
+			want:   []string{"select $1", "select $2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSQL(tt.script, tt.backslashEscape)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSQL(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
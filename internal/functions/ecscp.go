@@ -0,0 +1,67 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// containerPath is a file inside a container, written as task:container:/path
+type containerPath struct {
+	TaskID    string
+	Container string
+	Path      string
+}
+
+// Parses task:container:/path, reporting false for local paths
+func parseContainerPath(spec string) (*containerPath, bool) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || !strings.HasPrefix(parts[2], "/") {
+		return nil, false
+	}
+	return &containerPath{TaskID: parts[0], Container: parts[1], Path: parts[2]}, true
+}
+
+// Copies a file or directory between the local machine and a container in a selected cluster.
+// Exactly one of src and dst must be a task:container:/path.
+func ExecuteECSCopy(src, dst string) error {
+	remoteSrc, srcIsRemote := parseContainerPath(src)
+	remoteDst, dstIsRemote := parseContainerPath(dst)
+	if srcIsRemote == dstIsRemote {
+		return fmt.Errorf("exactly one of the source and destination must be task:container:/path")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Copy in the requested direction
+	if srcIsRemote {
+		localPath := dst
+		if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+			localPath = filepath.Join(localPath, path.Base(remoteSrc.Path))
+		}
+		fmt.Printf("Copying %s from %s/%s to %s\n", remoteSrc.Path, remoteSrc.TaskID, remoteSrc.Container, localPath)
+		return ecs.CopyFromContainer(selectedProfile, cluster, remoteSrc.TaskID, remoteSrc.Container, selectedRegion, remoteSrc.Path, localPath)
+	}
+
+	remotePath := remoteDst.Path
+	if strings.HasSuffix(remotePath, "/") {
+		remotePath += filepath.Base(src)
+	}
+	fmt.Printf("Copying %s to %s in %s/%s\n", src, remotePath, remoteDst.TaskID, remoteDst.Container)
+	return ecs.CopyToContainer(selectedProfile, cluster, remoteDst.TaskID, remoteDst.Container, selectedRegion, src, remotePath)
+}
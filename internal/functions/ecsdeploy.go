@@ -0,0 +1,133 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Registers a new revision of a selected service's task definition with the given container images
+// (name=image, name=:tag or name=@digest), deploys it and follows the rollout, reverting to the
+// previous revision if it fails
+func ExecuteECSDeploy(imageArgs []string, autoApprove bool, timeout time.Duration) error {
+	if len(imageArgs) == 0 {
+		return fmt.Errorf("at least one --image container=image is required")
+	}
+	images := map[string]string{}
+	for _, arg := range imageArgs {
+		name, image, ok := strings.Cut(arg, "=")
+		if !ok || name == "" || image == "" || image == ":" || image == "@" {
+			return fmt.Errorf("invalid --image %q, expected container=image, container=:tag or container=@digest", arg)
+		}
+		images[name] = image
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	previous, err := ecs.DescribeTaskDefinition(svc.TaskDefinition, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Resolve bare tags and digests onto each container's repository, show the changes and confirm
+	containers := map[string]bool{}
+	for _, c := range previous.ContainerDefinitions {
+		containers[c.Name] = true
+	}
+	for name := range images {
+		if !containers[name] {
+			return fmt.Errorf("container %s is not in the task definition", name)
+		}
+	}
+	for _, c := range previous.ContainerDefinitions {
+		if image, ok := images[c.Name]; ok {
+			image = withImageReference(c.Image, image)
+			images[c.Name] = image
+			fmt.Printf("%s: %s -> %s\n", c.Name, c.Image, image)
+		}
+	}
+	if !autoApprove && !utils.ConfirmPrompt(fmt.Sprintf("Register a new revision of %s and deploy it to %s? (Y/N)", previous.Family, service)) {
+		return fmt.Errorf("deploy cancelled")
+	}
+
+	// Step 5: Register the new revision
+	input, err := ecs.GetTaskDefinitionInput(previous.TaskDefinitionArn, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if err := ecs.SetContainerImages(input, images); err != nil {
+		return err
+	}
+	registered, err := ecs.RegisterTaskDefinition(input, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Registered %s\n", registered.Name())
+
+	// Step 6: Deploy it and follow the rollout
+	deploymentID, err := ecs.DeployTaskDefinition(cluster, service, registered.TaskDefinitionArn, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Started deployment %s\n", deploymentID)
+	deployErr := ecs.FollowDeployment(cluster, service, deploymentID, selectedProfile, selectedRegion, timeout)
+	if deployErr == nil {
+		fmt.Printf("Service %s is running %s.\n", service, registered.Name())
+		return nil
+	}
+
+	// Step 7: Revert to the previous revision, unless the circuit breaker already rolled back to it
+	fmt.Printf("Deployment failed: %v\n", deployErr)
+	svc, err = ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if svc.TaskDefinition == previous.TaskDefinitionArn {
+		return fmt.Errorf("deployment of %s failed and the service was rolled back to %s", registered.Name(), previous.Name())
+	}
+	fmt.Printf("Reverting %s to %s\n", service, previous.Name())
+	revertID, err := ecs.DeployTaskDefinition(cluster, service, previous.TaskDefinitionArn, selectedProfile, selectedRegion)
+	if err != nil {
+		return fmt.Errorf("deployment of %s failed and reverting failed: %v", registered.Name(), err)
+	}
+	if err := ecs.FollowDeployment(cluster, service, revertID, selectedProfile, selectedRegion, timeout); err != nil {
+		return fmt.Errorf("deployment of %s failed and reverting to %s failed: %v", registered.Name(), previous.Name(), err)
+	}
+	return fmt.Errorf("deployment of %s failed and the service was reverted to %s", registered.Name(), previous.Name())
+}
+
+// Returns image unchanged if it is a full reference, or, for a bare :tag or @digest, the current
+// image's repository with that tag or digest in place of its own
+func withImageReference(current, image string) string {
+	if !strings.HasPrefix(image, ":") && !strings.HasPrefix(image, "@") {
+		return image
+	}
+	repository, _, _ := strings.Cut(current, "@")
+	// A colon after the last slash starts the tag; one before it belongs to a registry port
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return repository + image
+}
@@ -0,0 +1,72 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Enables ECS exec on a selected service, optionally grants the task role the ssmmessages
+// permissions, and rolls the service until its new tasks' exec agents are running
+func ExecuteECSEnableExec(addPolicy bool, timeout time.Duration) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Grant the task role the ssmmessages permissions
+	if addPolicy {
+		svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+		taskDef, err := ecs.DescribeTaskDefinition(svc.TaskDefinition, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+		if taskDef.TaskRoleArn == "" {
+			return fmt.Errorf("task definition %s has no task role to add the ssmmessages policy to", svc.TaskDefinition)
+		}
+		roleName := taskDef.TaskRoleArn[strings.LastIndex(taskDef.TaskRoleArn, "/")+1:]
+		if err := aws.AttachSSMMessagesPolicy(selectedProfile, selectedRegion, roleName); err != nil {
+			return err
+		}
+		fmt.Printf("Added inline policy ECSExecSSMMessages to role %s\n", roleName)
+	}
+
+	// Step 5: Enable execute command and force a new deployment
+	deploymentID, err := ecs.EnableExecuteCommand(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Enabled execute command on %s and started deployment %s\n", service, deploymentID)
+
+	// Step 6: Wait for the rollout and the new tasks' exec agents
+	deadline := time.Now().Add(timeout)
+	if err := ecs.FollowDeployment(cluster, service, deploymentID, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+	if err := ecs.WaitForExecAgents(cluster, deploymentID, selectedProfile, selectedRegion, time.Until(deadline)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Service %s is stable and ready for `infra ecs exec`.\n", service)
+	return nil
+}
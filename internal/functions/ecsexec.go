@@ -2,49 +2,208 @@ package functions
 
 import (
 	"fmt"
-	
+	"io"
+	"sync"
+	"text/tabwriter"
+
 	"raid/infra/internal/ecs"
 	"raid/infra/internal/utils"
 )
 
-func ExecuteECSExec() error {
+// ecsTarget is the container chosen through the interactive ECS prompts
+type ecsTarget struct {
+	Profile   string
+	Region    string
+	Cluster   string
+	Service   string // empty for standalone tasks
+	TaskID    string
+	Container *ecs.Container
+}
+
+// Logs in and prompts for the ECS cluster, task scope, task and container
+func selectECSTarget() (*ecsTarget, error) {
 	// Step 1: Login to AWS
 	selectedProfile, selectedRegion, err := utils.Login()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Step 2: Select ECS cluster
 	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Step 3: Select ECS service
-	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	// Step 3: Select the tasks to choose from: a service, a task family or the whole cluster
+	scope, err := ecs.SelectECSTaskScope(cluster, selectedProfile, selectedRegion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Step 4: Select ECS task
-	taskID, err := ecs.SelectECSTask(cluster, service, selectedProfile, selectedRegion)
+	task, err := ecs.SelectScopedECSTask(cluster, scope, selectedProfile, selectedRegion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Step 5: Select ECS container
-	containerName, err := ecs.SelectECSContainer(cluster, taskID, selectedProfile, selectedRegion)
+	container, err := ecs.SelectECSContainer(cluster, task.ID(), selectedProfile, selectedRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecsTarget{
+		Profile:   selectedProfile,
+		Region:    selectedRegion,
+		Cluster:   cluster,
+		Service:   task.ServiceName(),
+		TaskID:    task.ID(),
+		Container: container,
+	}, nil
+}
+
+func ExecuteECSExec(skipPreflight bool) error {
+	target, err := selectECSTarget()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cluster: %s, Service: %s, Task ID: %s, Container: %s\n", target.Cluster, utils.ValueOrDash(target.Service), target.TaskID, target.Container.Name)
+
+	// Step 6: Offer a shell through the EC2 host for EC2 launch type tasks started without ECS exec
+	task, err := ecs.DescribeECSTask(target.Cluster, target.TaskID, target.Profile, target.Region)
 	if err != nil {
 		return err
 	}
+	if !task.EnableExecuteCommand && task.ContainerInstanceArn != "" &&
+		utils.ConfirmPrompt("Task was started without ECS exec. Exec into the container through its EC2 host instead? (Y/N)") {
+		instanceID, err := ecs.GetTaskInstanceID(target.Cluster, task, target.Profile, target.Region)
+		if err != nil {
+			return err
+		}
+		return ecs.StartECSHostExecSession(target.Profile, target.Cluster, target.TaskID, target.Container, instanceID, target.Region)
+	}
 
-	fmt.Printf("Cluster: %s, Service: %s, Task ID: %s, Container: %s\n", cluster, service, taskID, containerName)
+	// Step 7: Run pre-flight checks
+	if !skipPreflight {
+		checks, err := runECSExecChecks(target)
+		if err != nil {
+			return err
+		}
+		if countFailedChecks(checks) > 0 {
+			printDoctorReport(checks)
+			if !utils.ConfirmPrompt("Pre-flight checks failed. Start the session anyway? (Y/N)") {
+				return fmt.Errorf("ECS exec pre-flight checks failed")
+			}
+		} else {
+			fmt.Println("Pre-flight checks passed.")
+		}
+	}
 
-	// Step 6: Start ECS exec session
-	err = ecs.StartECSExecSession(selectedProfile, cluster, taskID, containerName, selectedRegion)
+	// Step 8: Start ECS exec session
+	err = ecs.StartECSExecSession(target.Profile, target.Cluster, target.TaskID, target.Container.Name, target.Region)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Runs a single command in the selected container and returns its exit status. The command's
+// output is written to out and infra's own status lines to status.
+func ExecuteECSExecCommand(command string, out, status io.Writer) (int, error) {
+	target, err := selectECSTarget()
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Fprintf(status, "Cluster: %s, Service: %s, Task ID: %s, Container: %s\n", target.Cluster, utils.ValueOrDash(target.Service), target.TaskID, target.Container.Name)
+
+	return ecs.RunECSCommand(target.Profile, target.Cluster, target.TaskID, target.Container.Name, target.Region, command, out)
+}
+
+// Runs a single command in the same container of every task in a service, at most
+// concurrency tasks at a time, and returns 1 if any task's command failed. Output lines are written
+// to out, and infra's status lines and the per-task summary to status.
+func ExecuteECSExecAllTasks(command string, concurrency int, out, status io.Writer) (int, error) {
+	if concurrency < 1 {
+		return 0, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 4: Fetch every task in the service
+	taskIDs, err := ecs.GetECSTasks(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 5: Select the container by name from the first task
+	container, err := ecs.SelectECSContainer(cluster, taskIDs[0], selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+	containerName := container.Name
+
+	fmt.Fprintf(status, "Cluster: %s, Service: %s, Container: %s, Tasks: %d\n", cluster, service, containerName, len(taskIDs))
+
+	// Step 6: Run the command against every task with bounded concurrency
+	type taskResult struct {
+		status int
+		err    error
+	}
+	results := make([]taskResult, len(taskIDs))
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, taskID := range taskIDs {
+		wg.Add(1)
+		go func(i int, taskID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			prefixed := utils.NewPrefixWriter(out, &outputMu, fmt.Sprintf("[%s] ", taskID))
+			exitStatus, err := ecs.RunECSCommand(selectedProfile, cluster, taskID, containerName, selectedRegion, command, prefixed)
+			results[i] = taskResult{status: exitStatus, err: err}
+		}(i, taskID)
+	}
+	wg.Wait()
+
+	// Step 7: Summarise per-task exit codes
+	exitStatus := 0
+	fmt.Fprintln(status)
+	w := tabwriter.NewWriter(status, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tEXIT\tERROR")
+	for i, taskID := range taskIDs {
+		result := results[i]
+		errText := "-"
+		if result.err != nil {
+			errText = result.err.Error()
+		}
+		if result.err != nil || result.status != 0 {
+			exitStatus = 1
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", taskID, result.status, errText)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return exitStatus, nil
+}
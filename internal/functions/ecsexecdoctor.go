@@ -0,0 +1,410 @@
+package functions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ec2"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Result of a single pre-flight check
+const (
+	checkPass = "PASS"
+	checkFail = "FAIL"
+	checkWarn = "WARN"
+	checkSkip = "SKIP"
+)
+
+// doctorCheck is one line of the ECS exec pre-flight report
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Fix    string
+}
+
+// execDoctor holds everything the pre-flight checks inspect for one container
+type execDoctor struct {
+	target  *ecsTarget
+	cluster *ecs.Cluster
+	service *ecs.Service
+	task    *ecs.Task
+	taskDef *ecs.TaskDefinition
+}
+
+// Runs the ECS exec pre-flight checks against a selected container and prints the report.
+// Returns an error if any check failed.
+func ExecuteECSExecDoctor() error {
+	target, err := selectECSTarget()
+	if err != nil {
+		return err
+	}
+
+	checks, err := runECSExecChecks(target)
+	if err != nil {
+		return err
+	}
+	printDoctorReport(checks)
+
+	if failed := countFailedChecks(checks); failed > 0 {
+		return fmt.Errorf("%d pre-flight check(s) failed", failed)
+	}
+	return nil
+}
+
+// Gathers the cluster, service, task and task definition and runs every pre-flight check
+func runECSExecChecks(target *ecsTarget) ([]doctorCheck, error) {
+	d := &execDoctor{target: target}
+
+	var err error
+	if d.cluster, err = ecs.DescribeECSCluster(target.Cluster, target.Profile, target.Region); err != nil {
+		return nil, err
+	}
+	if target.Service != "" {
+		if d.service, err = ecs.DescribeECSService(target.Cluster, target.Service, target.Profile, target.Region); err != nil {
+			return nil, err
+		}
+	}
+	if d.task, err = ecs.DescribeECSTask(target.Cluster, target.TaskID, target.Profile, target.Region); err != nil {
+		return nil, err
+	}
+	if d.taskDef, err = ecs.DescribeTaskDefinition(d.task.TaskDefinitionArn, target.Profile, target.Region); err != nil {
+		return nil, err
+	}
+
+	return []doctorCheck{
+		d.checkServiceExecEnabled(),
+		d.checkTaskExecEnabled(),
+		d.checkExecAgent(),
+		d.checkPlatformVersion(),
+		d.checkTaskRolePermissions(),
+		d.checkKMSKey(),
+		d.checkSessionLogging(),
+		d.checkNetworkPath(),
+	}, nil
+}
+
+func (d *execDoctor) checkServiceExecEnabled() doctorCheck {
+	check := doctorCheck{Name: "Service enableExecuteCommand"}
+	if d.service == nil {
+		check.Status = checkSkip
+		check.Detail = "task was not started by a service"
+		return check
+	}
+	if d.service.EnableExecuteCommand {
+		check.Status = checkPass
+		check.Detail = fmt.Sprintf("enabled on %s", d.service.ServiceName)
+		return check
+	}
+	check.Status = checkFail
+	check.Detail = fmt.Sprintf("disabled on %s", d.service.ServiceName)
+	check.Fix = "Run `infra ecs enable-exec` to enable it and roll the service"
+	return check
+}
+
+func (d *execDoctor) checkTaskExecEnabled() doctorCheck {
+	check := doctorCheck{Name: "Task enableExecuteCommand"}
+	if d.task.EnableExecuteCommand {
+		check.Status = checkPass
+		check.Detail = "task was started with execute command enabled"
+		return check
+	}
+	check.Status = checkFail
+	check.Detail = "task was started before execute command was enabled"
+	check.Fix = "Run `infra ecs enable-exec` to roll the service onto new tasks"
+	if d.service == nil {
+		check.Fix = "Run a new task with --enable-execute-command"
+	}
+	return check
+}
+
+func (d *execDoctor) checkExecAgent() doctorCheck {
+	check := doctorCheck{Name: "ExecuteCommandAgent status"}
+	for _, container := range d.task.Containers {
+		if container.Name != d.target.Container.Name {
+			continue
+		}
+		status := container.ExecAgentStatus()
+		switch status {
+		case "RUNNING":
+			check.Status = checkPass
+			check.Detail = fmt.Sprintf("RUNNING in container %s", container.Name)
+		case "PENDING":
+			check.Status = checkWarn
+			check.Detail = fmt.Sprintf("PENDING in container %s", container.Name)
+			check.Fix = "Wait a minute for the agent to start, then try again"
+		case "":
+			check.Status = checkFail
+			check.Detail = fmt.Sprintf("container %s has no ExecuteCommandAgent", container.Name)
+			check.Fix = "The task was not started with execute command enabled; run `infra ecs enable-exec` to replace it"
+		default:
+			check.Status = checkFail
+			check.Detail = fmt.Sprintf("%s in container %s", status, container.Name)
+			for _, agent := range container.ManagedAgents {
+				if agent.Name == ecs.ExecuteCommandAgent && agent.Reason != "" {
+					check.Detail += fmt.Sprintf(" (%s)", agent.Reason)
+				}
+			}
+			check.Fix = "The agent could not reach SSM; check the task role permissions and network path below, then replace the task"
+		}
+		return check
+	}
+	check.Status = checkFail
+	check.Detail = fmt.Sprintf("container %s not found in task", d.target.Container.Name)
+	return check
+}
+
+func (d *execDoctor) checkPlatformVersion() doctorCheck {
+	check := doctorCheck{Name: "Fargate platform version"}
+	if d.task.LaunchType != "FARGATE" {
+		check.Status = checkSkip
+		check.Detail = fmt.Sprintf("launch type is %s", d.task.LaunchType)
+		return check
+	}
+	// Windows Fargate tasks support exec from platform version 1.0.0
+	minMinor := 4
+	if d.task.PlatformFamily != "" && !strings.EqualFold(d.task.PlatformFamily, "Linux") {
+		minMinor = 0
+	}
+	if versionAtLeast(d.task.PlatformVersion, 1, minMinor, 0) {
+		check.Status = checkPass
+		check.Detail = d.task.PlatformVersion
+		return check
+	}
+	check.Status = checkFail
+	check.Detail = fmt.Sprintf("%s does not support ECS exec (requires 1.%d.0 or later)", d.task.PlatformVersion, minMinor)
+	check.Fix = "Run a new task with --platform-version LATEST"
+	if d.service != nil {
+		check.Fix = fmt.Sprintf("aws ecs update-service --cluster %s --service %s --platform-version LATEST --force-new-deployment", d.target.Cluster, d.service.ServiceName)
+	}
+	return check
+}
+
+func (d *execDoctor) checkTaskRolePermissions() doctorCheck {
+	check := doctorCheck{Name: "Task role ssmmessages permissions"}
+	roleARN := d.taskRoleARN()
+	if roleARN == "" {
+		check.Status = checkFail
+		check.Detail = "task definition has no task role"
+		check.Fix = fmt.Sprintf("Add a task role allowing %s to the task definition", strings.Join(aws.SSMMessagesActions, ", "))
+		return check
+	}
+	return d.simulateTaskRole(check, roleARN, aws.SSMMessagesActions, nil)
+}
+
+func (d *execDoctor) checkKMSKey() doctorCheck {
+	check := doctorCheck{Name: "Exec session KMS key"}
+	keyID := d.cluster.Configuration.ExecuteCommandConfiguration.KmsKeyID
+	if keyID == "" {
+		check.Status = checkPass
+		check.Detail = "no KMS key configured on the cluster"
+		return check
+	}
+	roleARN := d.taskRoleARN()
+	if roleARN == "" {
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("cluster encrypts sessions with %s but the task has no role", keyID)
+		check.Fix = "Add a task role allowing kms:Decrypt on the cluster's KMS key"
+		return check
+	}
+	var resources []string
+	if strings.HasPrefix(keyID, "arn:") {
+		resources = []string{keyID}
+	}
+	return d.simulateTaskRole(check, roleARN, []string{"kms:Decrypt"}, resources)
+}
+
+func (d *execDoctor) checkSessionLogging() doctorCheck {
+	check := doctorCheck{Name: "Exec session logging"}
+	config := d.cluster.Configuration.ExecuteCommandConfiguration
+	if config.Logging != "OVERRIDE" {
+		check.Status = checkPass
+		check.Detail = fmt.Sprintf("logging is %s", utils.ValueOrDefault(config.Logging, "DEFAULT"))
+		return check
+	}
+
+	var actions, resources []string
+	logConfig := config.LogConfiguration
+	if logConfig.CloudWatchLogGroupName != "" {
+		actions = append(actions, "logs:DescribeLogGroups", "logs:CreateLogStream", "logs:DescribeLogStreams", "logs:PutLogEvents")
+	}
+	if logConfig.S3BucketName != "" {
+		actions = append(actions, "s3:PutObject", "s3:GetEncryptionConfiguration")
+		resources = append(resources,
+			fmt.Sprintf("arn:aws:s3:::%s", logConfig.S3BucketName),
+			fmt.Sprintf("arn:aws:s3:::%s/%s*", logConfig.S3BucketName, logConfig.S3KeyPrefix))
+	}
+	if len(actions) == 0 {
+		check.Status = checkPass
+		check.Detail = "logging is OVERRIDE with no destination configured"
+		return check
+	}
+
+	roleARN := d.taskRoleARN()
+	if roleARN == "" {
+		check.Status = checkFail
+		check.Detail = "cluster logs sessions but the task has no role"
+		check.Fix = fmt.Sprintf("Add a task role allowing %s", strings.Join(actions, ", "))
+		return check
+	}
+	return d.simulateTaskRole(check, roleARN, actions, resources)
+}
+
+func (d *execDoctor) checkNetworkPath() doctorCheck {
+	check := doctorCheck{Name: "Network path to SSM"}
+	subnetID := d.task.SubnetID()
+	if subnetID == "" {
+		check.Status = checkSkip
+		check.Detail = "task does not use awsvpc networking; the container instance needs its own path to SSM"
+		return check
+	}
+
+	subnet, err := ec2.GetSubnet(subnetID, d.target.Profile, d.target.Region)
+	if err != nil {
+		return warnCheck(check, err)
+	}
+	route, err := ec2.GetSubnetDefaultRoute(subnet, d.target.Profile, d.target.Region)
+	if err != nil {
+		return warnCheck(check, err)
+	}
+	// A standalone task's public IP setting is unknown, so only a NAT route or VPC endpoints count for it
+	publicIP := d.service != nil && d.service.NetworkConfiguration.AwsvpcConfiguration.AssignPublicIP == "ENABLED"
+	if route != "" && (!strings.HasPrefix(route, "igw-") || publicIP) {
+		check.Status = checkPass
+		check.Detail = fmt.Sprintf("subnet %s routes to the internet via %s", subnetID, route)
+		return check
+	}
+
+	// Private subnet: the agent needs interface endpoints for every service it talks to
+	required := []string{"ssmmessages"}
+	config := d.cluster.Configuration.ExecuteCommandConfiguration
+	if config.KmsKeyID != "" {
+		required = append(required, "kms")
+	}
+	if config.Logging == "OVERRIDE" && config.LogConfiguration.CloudWatchLogGroupName != "" {
+		required = append(required, "logs")
+	}
+	if config.Logging == "OVERRIDE" && config.LogConfiguration.S3BucketName != "" {
+		required = append(required, "s3")
+	}
+
+	endpoints, err := ec2.GetVPCEndpoints(subnet.VpcID, d.target.Profile, d.target.Region)
+	if err != nil {
+		return warnCheck(check, err)
+	}
+	var missing, noPrivateDNS []string
+	for _, name := range required {
+		serviceName := fmt.Sprintf("com.amazonaws.%s.%s", d.target.Region, name)
+		found := false
+		for _, endpoint := range endpoints {
+			if endpoint.ServiceName != serviceName || endpoint.State != "available" {
+				continue
+			}
+			found = true
+			if endpoint.VpcEndpointType == "Interface" && !endpoint.PrivateDNSEnabled {
+				noPrivateDNS = append(noPrivateDNS, endpoint.VpcEndpointID)
+			}
+		}
+		if !found {
+			missing = append(missing, serviceName)
+		}
+	}
+
+	switch {
+	case len(missing) > 0:
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("subnet %s has no internet route and %s is missing VPC endpoints: %s", subnetID, subnet.VpcID, strings.Join(missing, ", "))
+		check.Fix = fmt.Sprintf("Create interface VPC endpoints (with private DNS) for %s in %s, or route the subnet through a NAT gateway", strings.Join(missing, ", "), subnet.VpcID)
+	case len(noPrivateDNS) > 0:
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("VPC endpoints without private DNS: %s", strings.Join(noPrivateDNS, ", "))
+		check.Fix = "Enable private DNS on the listed VPC endpoints"
+	default:
+		check.Status = checkPass
+		check.Detail = fmt.Sprintf("subnet %s reaches SSM through VPC endpoints in %s", subnetID, subnet.VpcID)
+	}
+	return check
+}
+
+// Simulates the task role for actions and fills in the check's result
+func (d *execDoctor) simulateTaskRole(check doctorCheck, roleARN string, actions, resources []string) doctorCheck {
+	denied, err := aws.SimulatePrincipalActions(d.target.Profile, d.target.Region, roleARN, actions, resources)
+	if err != nil {
+		return warnCheck(check, err)
+	}
+	roleName := roleARN[strings.LastIndex(roleARN, "/")+1:]
+	if len(denied) == 0 {
+		check.Status = checkPass
+		check.Detail = fmt.Sprintf("%s allows %s", roleName, strings.Join(actions, ", "))
+		return check
+	}
+	check.Status = checkFail
+	check.Detail = fmt.Sprintf("%s is not allowed %s", roleName, strings.Join(denied, ", "))
+	check.Fix = fmt.Sprintf("Add a policy to role %s allowing %s", roleName, strings.Join(denied, ", "))
+	if strings.HasPrefix(denied[0], "ssmmessages:") {
+		check.Fix += " (`infra ecs enable-exec --add-policy` does this)"
+	}
+	return check
+}
+
+// Returns the task role the task runs with, honouring task-level overrides
+func (d *execDoctor) taskRoleARN() string {
+	if d.task.Overrides.TaskRoleArn != "" {
+		return d.task.Overrides.TaskRoleArn
+	}
+	return d.taskDef.TaskRoleArn
+}
+
+func warnCheck(check doctorCheck, err error) doctorCheck {
+	check.Status = checkWarn
+	check.Detail = fmt.Sprintf("could not check: %v", err)
+	return check
+}
+
+func printDoctorReport(checks []doctorCheck) {
+	fmt.Println("ECS exec pre-flight checks:")
+	for _, check := range checks {
+		fmt.Printf("  [%s] %s: %s\n", check.Status, check.Name, check.Detail)
+		if check.Fix != "" {
+			fmt.Printf("         Fix: %s\n", check.Fix)
+		}
+	}
+}
+
+func countFailedChecks(checks []doctorCheck) int {
+	failed := 0
+	for _, check := range checks {
+		if check.Status == checkFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Reports whether a dotted version such as "1.4.0" is at least major.minor.patch.
+// "LATEST" is always new enough.
+func versionAtLeast(version string, major, minor, patch int) bool {
+	if version == "LATEST" {
+		return true
+	}
+	want := []int{major, minor, patch}
+	parts := strings.Split(version, ".")
+	for i := 0; i < len(want); i++ {
+		got := 0
+		if i < len(parts) {
+			n, err := strconv.Atoi(parts[i])
+			if err != nil {
+				return false
+			}
+			got = n
+		}
+		if got != want[i] {
+			return got > want[i]
+		}
+	}
+	return true
+}
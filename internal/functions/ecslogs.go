@@ -0,0 +1,189 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// How often new log events are fetched when following
+const logsPollInterval = 2 * time.Second
+
+// ANSI colours cycled through to tell tasks apart when interleaving their logs
+var taskColours = []string{"\033[36m", "\033[33m", "\033[35m", "\033[32m", "\033[34m", "\033[31m"}
+
+const colourReset = "\033[0m"
+
+// logSource is one container log stream and the label its lines are printed with
+type logSource struct {
+	group  string
+	region string
+	stream string
+	label  string
+}
+
+// Prints the CloudWatch logs of the selected container, or of that container in every task
+// of the service with allTasks, optionally following them until interrupted
+func ExecuteECSLogs(follow bool, since time.Duration, filter string, allTasks bool) error {
+	var profile, region, cluster, containerName string
+	var taskIDs []string
+
+	if allTasks {
+		// Step 1: Login to AWS
+		selectedProfile, selectedRegion, err := utils.Login()
+		if err != nil {
+			return err
+		}
+		profile, region = selectedProfile, selectedRegion
+
+		// Step 2: Select ECS cluster
+		cluster, err = ecs.SelectECSCluster(profile, region)
+		if err != nil {
+			return err
+		}
+
+		// Step 3: Select ECS service
+		service, err := ecs.SelectECSService(cluster, profile, region)
+		if err != nil {
+			return err
+		}
+
+		// Step 4: Fetch every task in the service
+		taskIDs, err = ecs.GetECSTasks(cluster, service, profile, region)
+		if err != nil {
+			return err
+		}
+
+		// Step 5: Select the container by name from the first task
+		container, err := ecs.SelectECSContainer(cluster, taskIDs[0], profile, region)
+		if err != nil {
+			return err
+		}
+		containerName = container.Name
+		fmt.Fprintf(os.Stderr, "Cluster: %s, Service: %s, Container: %s, Tasks: %d\n", cluster, service, containerName, len(taskIDs))
+	} else {
+		target, err := selectECSTarget()
+		if err != nil {
+			return err
+		}
+		profile, region, cluster, containerName = target.Profile, target.Region, target.Cluster, target.Container.Name
+		taskIDs = []string{target.TaskID}
+		fmt.Fprintf(os.Stderr, "Cluster: %s, Service: %s, Task ID: %s, Container: %s\n", target.Cluster, utils.ValueOrDash(target.Service), target.TaskID, target.Container.Name)
+	}
+
+	// Step 6: Derive each task's log stream from its task definition's awslogs configuration
+	sources, err := containerLogSources(profile, region, cluster, containerName, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	// Step 7: Print the window of past events, then keep polling if following
+	colour := allTasks && isTerminal(os.Stdout)
+	return tailLogSources(profile, sources, time.Now().Add(-since), filter, follow, colour)
+}
+
+// Returns the awslogs stream of the named container in each task
+func containerLogSources(profile, region, cluster, containerName string, taskIDs []string) ([]logSource, error) {
+	tasks, err := ecs.DescribeECSTasks(cluster, taskIDs, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	taskDefinitions := map[string]*ecs.TaskDefinition{}
+	var sources []logSource
+	for _, task := range tasks {
+		taskDefinition, ok := taskDefinitions[task.TaskDefinitionArn]
+		if !ok {
+			taskDefinition, err = ecs.DescribeTaskDefinition(task.TaskDefinitionArn, profile, region)
+			if err != nil {
+				return nil, err
+			}
+			taskDefinitions[task.TaskDefinitionArn] = taskDefinition
+		}
+
+		container := taskDefinition.Container(containerName)
+		if container == nil {
+			return nil, fmt.Errorf("container %s is not in task definition %s", containerName, task.TaskDefinitionArn)
+		}
+		group, logRegion, stream, err := container.AWSLogsStream(task.ID(), region)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, logSource{group: group, region: logRegion, stream: stream, label: task.ID()})
+	}
+	return sources, nil
+}
+
+// Prints events from the log sources in timestamp order, polling for new events until interrupted if follow is set
+func tailLogSources(profile string, sources []logSource, since time.Time, filter string, follow, colour bool) error {
+	// One tailer per log group, since a poll reads several streams of a single group
+	type groupKey struct{ group, region string }
+	streams := map[groupKey][]string{}
+	var keys []groupKey
+	labels := map[string]string{}
+	for i, source := range sources {
+		key := groupKey{source.group, source.region}
+		if _, ok := streams[key]; !ok {
+			keys = append(keys, key)
+		}
+		streams[key] = append(streams[key], source.stream)
+
+		label := ""
+		if len(sources) > 1 {
+			label = fmt.Sprintf("[%s] ", source.label)
+			if colour {
+				label = taskColours[i%len(taskColours)] + label + colourReset
+			}
+		}
+		labels[source.group+"|"+source.stream] = label
+	}
+
+	var tailers []*aws.LogTailer
+	for _, key := range keys {
+		tailer, err := aws.NewLogTailer(profile, key.region, key.group, streams[key], filter, since)
+		if err != nil {
+			return err
+		}
+		tailers = append(tailers, tailer)
+	}
+
+	for {
+		type labelledEvent struct {
+			aws.LogEvent
+			label string
+		}
+		var events []labelledEvent
+		for i, tailer := range tailers {
+			polled, err := tailer.Poll()
+			if err != nil {
+				return err
+			}
+			for _, event := range polled {
+				events = append(events, labelledEvent{event, labels[keys[i].group+"|"+event.StreamName]})
+			}
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Timestamp.Before(events[j].Timestamp)
+		})
+		for _, event := range events {
+			fmt.Printf("%s%s %s\n", event.label, event.Timestamp.Format(time.RFC3339), strings.TrimRight(event.Message, "\n"))
+		}
+
+		if !follow {
+			return nil
+		}
+		time.Sleep(logsPollInterval)
+	}
+}
+
+// Reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"fmt"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Forwards a local port to a port on the selected ECS container
+func ExecuteECSPortForwarding() error {
+	target, err := selectECSTarget()
+	if err != nil {
+		return err
+	}
+
+	// Step 6: Select container port
+	containerPort, err := ecs.SelectContainerPort(target.Cluster, target.TaskID, target.Container.Name, target.Profile, target.Region)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cluster: %s, Service: %s, Task ID: %s, Runtime ID: %s, Container: %s, Port: %d\n", target.Cluster, utils.ValueOrDash(target.Service), target.TaskID, target.Container.RuntimeID, target.Container.Name, containerPort)
+
+	// Step 7: Start SSM port forwarding session
+	return ecs.StartECSContainerPortForwardSession(target.Profile, target.Cluster, target.TaskID, target.Container, target.Region, containerPort)
+}
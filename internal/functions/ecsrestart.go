@@ -0,0 +1,65 @@
+package functions
+
+import (
+	"fmt"
+	"time"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Forces a new deployment of a selected service and follows the rollout until it completes,
+// returning an error if the deployment fails or is rolled back
+func ExecuteECSRestart(autoApprove bool, timeout time.Duration) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	breaker := svc.DeploymentConfiguration.DeploymentCircuitBreaker
+	fmt.Printf("Service %s runs %d/%d task(s) of %s\n", service, svc.RunningCount, svc.DesiredCount, ecs.TaskDefinitionName(svc.TaskDefinition))
+	switch {
+	case breaker.Enable && breaker.Rollback:
+		fmt.Println("Deployment circuit breaker is enabled with rollback.")
+	case breaker.Enable:
+		fmt.Println("Deployment circuit breaker is enabled without rollback.")
+	default:
+		fmt.Println("Deployment circuit breaker is disabled; a failing deployment will keep retrying until the timeout.")
+	}
+
+	if !autoApprove && !utils.ConfirmPrompt(fmt.Sprintf("Replace every task of %s? (Y/N)", service)) {
+		return fmt.Errorf("restart cancelled")
+	}
+
+	// Step 4: Force a new deployment
+	deploymentID, err := ecs.ForceNewDeployment(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Started deployment %s\n", deploymentID)
+
+	// Step 5: Follow the rollout
+	if err := ecs.FollowDeployment(cluster, service, deploymentID, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+
+	fmt.Printf("Service %s restarted successfully.\n", service)
+	return nil
+}
@@ -0,0 +1,147 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// How often a one-off task's status is checked
+const runPollInterval = 2 * time.Second
+
+// Runs a one-off task with a selected service's task definition and network configuration,
+// overriding an essential container's command, streams its logs until it stops or the timeout
+// passes, and returns that container's exit code
+func ExecuteECSRun(command string, timeout time.Duration) (int, error) {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 3: Select the ECS service whose configuration is copied
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+	svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+	taskDefinition, err := ecs.DescribeTaskDefinition(svc.TaskDefinition, selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	// Step 4: Select the essential container to run the command in
+	var essential []string
+	for _, c := range taskDefinition.ContainerDefinitions {
+		if c.IsEssential() {
+			essential = append(essential, c.Name)
+		}
+	}
+	if len(essential) == 0 {
+		return 0, fmt.Errorf("task definition %s has no essential container", svc.TaskDefinition)
+	}
+	containerName := essential[0]
+	if len(essential) > 1 {
+		containerName, err = utils.PromptSelection(essential, "ECS Container")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Step 5: Run the task
+	var override []string
+	if command != "" {
+		override = []string{"sh", "-c", command}
+	}
+	started := time.Now()
+	task, err := ecs.RunTask(ecs.RunTaskInputFromService(cluster, svc, containerName, override), selectedProfile, selectedRegion)
+	if err != nil {
+		return 0, err
+	}
+	taskID := task.ID()
+	fmt.Fprintf(os.Stderr, "Started task %s from %s (container %s)\n", taskID, ecs.TaskDefinitionName(svc.TaskDefinition), containerName)
+
+	// Step 6: Stream the container's logs until the task stops
+	var tailer *aws.LogTailer
+	group, logRegion, stream, err := taskDefinition.Container(containerName).AWSLogsStream(taskID, selectedRegion)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Not streaming logs: %v\n", err)
+	} else {
+		tailer, err = aws.NewLogTailer(selectedProfile, logRegion, group, []string{stream}, "", started.Add(-time.Minute))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	status := ""
+	deadline := started.Add(timeout)
+	for {
+		task, err = ecs.DescribeECSTask(cluster, taskID, selectedProfile, selectedRegion)
+		if err != nil {
+			return 0, err
+		}
+		if task.LastStatus != status {
+			status = task.LastStatus
+			fmt.Fprintf(os.Stderr, "Task %s is %s\n", taskID, status)
+		}
+
+		// The log stream only exists once the container has started, so errors are ignored while it runs
+		if tailer != nil && (status == "RUNNING" || status == "DEPROVISIONING") {
+			printLogEvents(tailer)
+		}
+		if status == "STOPPED" {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timed out after %s waiting for task %s to stop; it is still %s", timeout, taskID, status)
+		}
+		time.Sleep(runPollInterval)
+	}
+
+	// Give CloudWatch a moment to ingest the final lines before the last fetch
+	if tailer != nil {
+		time.Sleep(runPollInterval)
+		if err := printLogEvents(tailer); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fetch logs: %v\n", err)
+		}
+	}
+
+	// Step 7: Report how the task stopped and return the container's exit code
+	fmt.Fprintf(os.Stderr, "Task %s stopped: %s\n", taskID, task.StopSummary())
+	for _, c := range task.Containers {
+		if c.Name != containerName {
+			continue
+		}
+		if c.ExitCode == nil {
+			return 0, fmt.Errorf("container %s did not exit normally", containerName)
+		}
+		return *c.ExitCode, nil
+	}
+	return 0, fmt.Errorf("container %s not found in task %s", containerName, taskID)
+}
+
+// Prints the new events from a log tailer to stdout
+func printLogEvents(tailer *aws.LogTailer) error {
+	events, err := tailer.Poll()
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		fmt.Println(strings.TrimRight(event.Message, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// scaleState is what `infra ecs scale` changed on a service, saved so --restore can put it back
+type scaleState struct {
+	Cluster        string                 `json:"cluster"`
+	Service        string                 `json:"service"`
+	DesiredCount   int                    `json:"desiredCount"`
+	ScalableTarget *aws.ECSScalableTarget `json:"scalableTarget,omitempty"`
+	SavedAt        time.Time              `json:"savedAt"`
+}
+
+// Returns where the saved settings of a service are kept (~/.infra/scale/<profile>_<region>_<cluster>_<service>.json)
+func scaleStatePath(profile, region, cluster, service string) (string, error) {
+	dir, err := utils.InfraDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "scale")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s_%s.json", profile, region, cluster, service)), nil
+}
+
+// Sets the desired count of a selected service, pinning or suspending its Application Auto Scaling
+// target so it does not scale the service back, and waits until the running count matches.
+// With restore, the settings saved before the first scale are put back instead. Either change is
+// confirmed first unless autoApprove is set.
+func ExecuteECSScale(count int, suspend, restore, autoApprove bool, timeout time.Duration) error {
+	if !restore && count < 0 {
+		return fmt.Errorf("--count is required unless --restore is used")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	statePath, err := scaleStatePath(selectedProfile, selectedRegion, cluster, service)
+	if err != nil {
+		return err
+	}
+	if restore {
+		return restoreECSScale(statePath, cluster, service, selectedProfile, selectedRegion, autoApprove, timeout)
+	}
+
+	// Step 4: Work out the new auto scaling settings, show the change and confirm
+	svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	target, err := aws.GetECSScalableTarget(selectedProfile, selectedRegion, cluster, service)
+	if err != nil {
+		return err
+	}
+	var updated aws.ECSScalableTarget
+	if target != nil {
+		updated = *target
+		if suspend {
+			updated.DynamicScalingInSuspended = true
+			updated.DynamicScalingOutSuspended = true
+			updated.ScheduledScalingSuspended = true
+			if int32(count) < updated.MinCapacity {
+				updated.MinCapacity = int32(count)
+			}
+			if int32(count) > updated.MaxCapacity {
+				updated.MaxCapacity = int32(count)
+			}
+		} else {
+			updated.MinCapacity = int32(count)
+			updated.MaxCapacity = int32(count)
+		}
+	}
+	fmt.Printf("Desired count: %d -> %d\n", svc.DesiredCount, count)
+	if target != nil {
+		fmt.Printf("Auto scaling: min %d -> %d, max %d -> %d", target.MinCapacity, updated.MinCapacity, target.MaxCapacity, updated.MaxCapacity)
+		if suspend {
+			fmt.Print(", scaling activities suspended")
+		}
+		fmt.Println()
+	}
+	if !autoApprove && !utils.ConfirmPrompt(fmt.Sprintf("Scale %s to %d task(s)? (Y/N)", service, count)) {
+		return fmt.Errorf("scale cancelled")
+	}
+
+	// Step 5: Save the current settings, unless an earlier scale already saved the originals
+	if _, err := os.Stat(statePath); errors.Is(err, os.ErrNotExist) {
+		state := scaleState{Cluster: cluster, Service: service, DesiredCount: svc.DesiredCount, ScalableTarget: target, SavedAt: time.Now()}
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(statePath, data, 0o600); err != nil {
+			return fmt.Errorf("failed to save current settings: %v", err)
+		}
+		fmt.Printf("Saved current settings to %s (desired count %d)\n", statePath, svc.DesiredCount)
+	} else {
+		fmt.Printf("Keeping the settings saved earlier in %s; use --restore to put them back\n", statePath)
+	}
+
+	// Step 6: Stop Application Auto Scaling from undoing the change
+	if target != nil {
+		if err := aws.PutECSScalableTarget(selectedProfile, selectedRegion, cluster, service, updated); err != nil {
+			return err
+		}
+		if suspend {
+			fmt.Printf("Suspended auto scaling (min %d, max %d)\n", updated.MinCapacity, updated.MaxCapacity)
+		} else {
+			fmt.Printf("Pinned auto scaling to min %d, max %d\n", updated.MinCapacity, updated.MaxCapacity)
+		}
+	}
+
+	// Step 7: Set the desired count and wait for it
+	if err := ecs.SetDesiredCount(cluster, service, count, selectedProfile, selectedRegion); err != nil {
+		return err
+	}
+	fmt.Printf("Scaling %s from %d to %d task(s)\n", service, svc.DesiredCount, count)
+	if err := ecs.WaitForRunningCount(cluster, service, count, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+	fmt.Printf("Service %s is running %d task(s).\n", service, count)
+	return nil
+}
+
+// Puts back the desired count and scalable target saved before the first scale, then removes the saved state
+func restoreECSScale(statePath, cluster, service, profile, region string, autoApprove bool, timeout time.Duration) error {
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no saved settings for %s; it was not scaled with infra", service)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read saved settings: %v", err)
+	}
+	var state scaleState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", statePath, err)
+	}
+
+	fmt.Printf("Desired count: %d as saved at %s\n", state.DesiredCount, state.SavedAt.Format(time.RFC3339))
+	if state.ScalableTarget != nil {
+		fmt.Printf("Auto scaling: min %d, max %d, as saved\n", state.ScalableTarget.MinCapacity, state.ScalableTarget.MaxCapacity)
+	}
+	if !autoApprove && !utils.ConfirmPrompt(fmt.Sprintf("Restore %s to its saved settings? (Y/N)", service)) {
+		return fmt.Errorf("restore cancelled")
+	}
+
+	if state.ScalableTarget != nil {
+		if err := aws.PutECSScalableTarget(profile, region, cluster, service, *state.ScalableTarget); err != nil {
+			return err
+		}
+		fmt.Printf("Restored auto scaling (min %d, max %d)\n", state.ScalableTarget.MinCapacity, state.ScalableTarget.MaxCapacity)
+	}
+	if err := ecs.SetDesiredCount(cluster, service, state.DesiredCount, profile, region); err != nil {
+		return err
+	}
+	fmt.Printf("Restoring %s to %d task(s) as saved at %s\n", service, state.DesiredCount, state.SavedAt.Format(time.RFC3339))
+	// Auto scaling is active again and may move the desired count, so wait for whatever it settles on
+	running, err := ecs.WaitForDesiredCount(cluster, service, profile, region, timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(statePath); err != nil {
+		return fmt.Errorf("failed to remove saved settings: %v", err)
+	}
+	fmt.Printf("Service %s is running %d task(s).\n", service, running)
+	return nil
+}
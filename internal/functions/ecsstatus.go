@@ -0,0 +1,163 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Clears the terminal and moves the cursor to the top left
+const clearScreen = "\033[H\033[2J"
+
+// Prints a service's deployments, task counts, recent events, target health and images,
+// redrawing it every interval with watch until interrupted. A failed redraw is reported and
+// retried on the next one rather than ending the watch.
+func ExecuteECSStatus(watch bool, interval time.Duration, events int) error {
+	if watch && interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select ECS service
+	service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Render the status, redrawing it in place when watching
+	for {
+		var buf bytes.Buffer
+		renderErr := renderServiceStatus(&buf, cluster, service, selectedProfile, selectedRegion, events)
+		if !watch {
+			if renderErr != nil {
+				return renderErr
+			}
+			_, err := buf.WriteTo(os.Stdout)
+			return err
+		}
+		fmt.Print(clearScreen)
+		fmt.Printf("Every %s, updated %s (Ctrl+C to stop)\n\n", interval, time.Now().Format("15:04:05"))
+		if renderErr != nil {
+			fmt.Printf("Error: %v\nRetrying in %s.\n", renderErr, interval)
+		} else if _, err := buf.WriteTo(os.Stdout); err != nil {
+			return err
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Writes the status of a service to w
+func renderServiceStatus(w io.Writer, cluster, service, profile, region string, events int) error {
+	svc, err := ecs.DescribeECSService(cluster, service, profile, region)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Service: %s (cluster %s)\n", svc.ServiceName, cluster)
+	fmt.Fprintf(w, "Tasks: %d running, %d pending, %d desired\n", svc.RunningCount, svc.PendingCount, svc.DesiredCount)
+
+	// Deployments, primary first as returned by ECS
+	fmt.Fprintln(w, "\nDeployments:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ID\tSTATUS\tTASK DEFINITION\tROLLOUT\tRUNNING\tPENDING\tDESIRED\tFAILED\tUPDATED")
+	for _, d := range svc.Deployments {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", d.ID, d.Status, ecs.TaskDefinitionName(d.TaskDefinition),
+			utils.ValueOrDash(d.RolloutState), d.RunningCount, d.PendingCount, d.DesiredCount, d.FailedTasks, utils.ValueOrDash(d.UpdatedAt))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, d := range svc.Deployments {
+		if d.RolloutStateReason != "" {
+			fmt.Fprintf(w, "  %s: %s\n", d.ID, d.RolloutStateReason)
+		}
+	}
+
+	// Target group health for attached load balancers
+	if len(svc.LoadBalancers) > 0 {
+		fmt.Fprintln(w, "\nTarget groups:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  TARGET GROUP\tCONTAINER\tTARGET\tSTATE\tREASON")
+		for _, lb := range svc.LoadBalancers {
+			if lb.TargetGroupArn == "" {
+				fmt.Fprintf(tw, "  %s (classic)\t%s:%d\t-\t-\t-\n", lb.LoadBalancerName, lb.ContainerName, lb.ContainerPort)
+				continue
+			}
+			targets, err := ecs.GetTargetHealth(lb.TargetGroupArn, profile, region)
+			if err != nil {
+				return err
+			}
+			if len(targets) == 0 {
+				fmt.Fprintf(tw, "  %s\t%s:%d\t-\tno targets\t-\n", targetGroupName(lb.TargetGroupArn), lb.ContainerName, lb.ContainerPort)
+			}
+			for _, t := range targets {
+				fmt.Fprintf(tw, "  %s\t%s:%d\t%s:%d\t%s\t%s\n", targetGroupName(lb.TargetGroupArn), lb.ContainerName, lb.ContainerPort,
+					t.Target.ID, t.Target.Port, t.TargetHealth.State, utils.ValueOrDash(t.TargetHealth.Reason))
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// Container images of each task definition in use
+	fmt.Fprintln(w, "\nImages:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  TASK DEFINITION\tCONTAINER\tIMAGE")
+	described := map[string]bool{}
+	for _, d := range svc.Deployments {
+		if described[d.TaskDefinition] {
+			continue
+		}
+		described[d.TaskDefinition] = true
+		taskDefinition, err := ecs.DescribeTaskDefinition(d.TaskDefinition, profile, region)
+		if err != nil {
+			return err
+		}
+		for _, c := range taskDefinition.ContainerDefinitions {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", ecs.TaskDefinitionName(d.TaskDefinition), c.Name, c.Image)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Most recent service events, newest first
+	if events > 0 {
+		fmt.Fprintln(w, "\nEvents:")
+		for i, e := range svc.Events {
+			if i == events {
+				break
+			}
+			fmt.Fprintf(w, "  %s  %s\n", e.CreatedAt, e.Message)
+		}
+	}
+	return nil
+}
+
+// Returns the name of a target group from its ARN (arn:...:targetgroup/name/id)
+func targetGroupName(arn string) string {
+	parts := strings.Split(arn, "/")
+	if len(parts) < 3 {
+		return arn
+	}
+	return parts[len(parts)-2]
+}
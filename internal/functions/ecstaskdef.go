@@ -0,0 +1,171 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Compares two task definition revisions. With no arguments, the selected service's running
+// revision is compared against the latest revision of its family; with one, that revision is
+// compared against the latest of its family.
+func ExecuteECSTaskDefDiff(args []string) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Resolve the older revision, from the arguments or the selected service
+	var from *ecs.TaskDefinition
+	if len(args) > 0 {
+		from, err = ecs.DescribeTaskDefinition(args[0], selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+	} else {
+		cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+		service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+		svc, err := ecs.DescribeECSService(cluster, service, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+		from, err = ecs.DescribeTaskDefinition(svc.TaskDefinition, selectedProfile, selectedRegion)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Step 3: Resolve the newer revision, defaulting to the latest of the family
+	toName := from.Family
+	if len(args) > 1 {
+		toName = args[1]
+	}
+	to, err := ecs.DescribeTaskDefinition(toName, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Print the differences
+	fmt.Printf("--- %s\n+++ %s\n", from.Name(), to.Name())
+	if from.TaskDefinitionArn == to.TaskDefinitionArn {
+		fmt.Println("Same revision; nothing to compare.")
+		return nil
+	}
+	changes := diffFields(from.Fields(), to.Fields())
+	if len(changes) == 0 {
+		fmt.Println("No differences.")
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	return nil
+}
+
+// Returns the removed (-), added (+) and changed (~) keys between two sets of fields, sorted by key
+func diffFields(from, to map[string]string) []string {
+	keys := map[string]bool{}
+	for key := range from {
+		keys[key] = true
+	}
+	for key := range to {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, key := range sorted {
+		oldValue, inFrom := from[key]
+		newValue, inTo := to[key]
+		switch {
+		case !inTo:
+			changes = append(changes, fmt.Sprintf("- %s: %s", key, oldValue))
+		case !inFrom:
+			changes = append(changes, fmt.Sprintf("+ %s: %s", key, newValue))
+		case oldValue != newValue:
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", key, oldValue, newValue))
+		}
+	}
+	return changes
+}
+
+// Prints the environment of the selected container as ECS resolves it: the task definition's
+// environment with the task's overrides applied, and its secrets, redacted unless reveal is set
+func ExecuteECSTaskDefEnv(reveal bool) error {
+	target, err := selectECSTarget()
+	if err != nil {
+		return err
+	}
+
+	task, err := ecs.DescribeECSTask(target.Cluster, target.TaskID, target.Profile, target.Region)
+	if err != nil {
+		return err
+	}
+	taskDefinition, err := ecs.DescribeTaskDefinition(task.TaskDefinitionArn, target.Profile, target.Region)
+	if err != nil {
+		return err
+	}
+	container := taskDefinition.Container(target.Container.Name)
+	if container == nil {
+		return fmt.Errorf("container %s is not in task definition %s", target.Container.Name, taskDefinition.Name())
+	}
+	fmt.Fprintf(os.Stderr, "Task %s, container %s, task definition %s\n", target.TaskID, container.Name, taskDefinition.Name())
+
+	// Overrides the task was started with take precedence over the task definition
+	env := map[string]string{}
+	for _, kv := range container.Environment {
+		env[kv.Name] = kv.Value
+	}
+	for _, override := range task.Overrides.ContainerOverrides {
+		if override.Name != container.Name {
+			continue
+		}
+		for _, kv := range override.Environment {
+			env[kv.Name] = kv.Value
+		}
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s=%s\n", name, env[name])
+	}
+
+	secrets := append([]ecs.Secret(nil), container.Secrets...)
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	for _, secret := range secrets {
+		if !reveal {
+			fmt.Printf("%s=<redacted> # from %s\n", secret.Name, secret.ValueFrom)
+			continue
+		}
+		value, err := aws.ResolveSecretReference(target.Profile, target.Region, secret.ValueFrom)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s=%s\n", secret.Name, value)
+	}
+
+	for _, file := range container.EnvironmentFiles {
+		fmt.Printf("# environment file (%s, not expanded): %s\n", strings.ToLower(file.Type), file.Value)
+	}
+	return nil
+}
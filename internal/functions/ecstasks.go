@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"raid/infra/internal/ecs"
+	"raid/infra/internal/utils"
+)
+
+// Lists the running or recently stopped tasks of a service, task family or whole cluster.
+// Stopped tasks are shown with their stop code, stopped reason and container exit codes.
+func ExecuteECSTasks(stopped bool) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select ECS cluster
+	cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Select the tasks to list
+	scope, err := ecs.SelectECSTaskScope(cluster, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Fetch and describe the tasks
+	desiredStatus := "RUNNING"
+	if stopped {
+		desiredStatus = "STOPPED"
+	}
+	taskIDs, err := ecs.GetScopedECSTasks(cluster, scope, desiredStatus, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if len(taskIDs) == 0 {
+		fmt.Printf("No %s tasks in %s.\n", strings.ToLower(desiredStatus), scope)
+		return nil
+	}
+	tasks, err := ecs.DescribeECSTasks(cluster, taskIDs, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 5: Print the tasks
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if !stopped {
+		fmt.Fprintln(w, "TASK\tTASK DEFINITION\tGROUP\tSTATUS\tSTARTED")
+		for _, task := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", task.ID(), task.TaskDefinitionName(), utils.ValueOrDash(task.Group),
+				task.LastStatus, utils.ValueOrDash(task.StartedAt))
+		}
+		return w.Flush()
+	}
+
+	fmt.Fprintln(w, "TASK\tTASK DEFINITION\tGROUP\tSTARTED\tSTOPPED\tSTOP CODE\tEXIT CODES\tREASON")
+	for _, task := range tasks {
+		var exitCodes []string
+		for _, c := range task.Containers {
+			if c.ExitCode != nil {
+				exitCodes = append(exitCodes, fmt.Sprintf("%s=%d", c.Name, *c.ExitCode))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", task.ID(), task.TaskDefinitionName(), utils.ValueOrDash(task.Group),
+			utils.ValueOrDash(task.StartedAt), utils.ValueOrDash(task.StoppedAt), utils.ValueOrDash(task.StopCode),
+			utils.ValueOrDash(strings.Join(exitCodes, ",")), utils.ValueOrDash(task.StoppedReason))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Container reasons are often the actual cause (e.g. OutOfMemoryError or CannotPullContainerError)
+	for _, task := range tasks {
+		for _, c := range task.Containers {
+			if c.Reason != "" {
+				fmt.Printf("%s %s: %s\n", task.ID(), c.Name, c.Reason)
+			}
+		}
+	}
+	return nil
+}
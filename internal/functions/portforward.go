@@ -2,6 +2,7 @@ package functions
 
 import (
 	"fmt"
+	"strconv"
 	
 	"raid/infra/internal/ec2"
 	"raid/infra/internal/ecs"
@@ -39,35 +40,40 @@ func ExecutePortForwarding() error {
 		if err != nil {
 			return err
 		}
-		err = ec2.StartEC2SSMSession(instanceID, selectedProfile, dbHost, selectedRegion, dbPort)
+		transport, err := utils.PromptSelection([]string{"SSM", "EC2 Instance Connect Endpoint"}, "Tunnel Transport")
 		if err != nil {
 			return err
 		}
-	} else if selection == "ECS" {
-		// ECS Port Forwarding
-		cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
+		if transport == "SSM" {
+			err = ec2.StartEC2SSMSession(instanceID, selectedProfile, dbHost, selectedRegion, dbPort)
+		} else {
+			err = startEC2EICESession(instanceID, selectedProfile, dbHost, selectedRegion, dbPort)
+		}
 		if err != nil {
 			return err
 		}
-		service, err := ecs.SelectECSService(cluster, selectedProfile, selectedRegion)
+	} else if selection == "ECS" {
+		// ECS Port Forwarding
+		cluster, err := ecs.SelectECSCluster(selectedProfile, selectedRegion)
 		if err != nil {
 			return err
 		}
-		taskID, err := ecs.SelectECSTask(cluster, service, selectedProfile, selectedRegion)
+		scope, err := ecs.SelectECSTaskScope(cluster, selectedProfile, selectedRegion)
 		if err != nil {
 			return err
 		}
-		runtimeID, err := ecs.GetTaskDetails(cluster, taskID, selectedProfile, selectedRegion)
+		task, err := ecs.SelectScopedECSTask(cluster, scope, selectedProfile, selectedRegion)
 		if err != nil {
 			return err
 		}
-		containerName, err := ecs.SelectECSContainer(cluster, taskID, selectedProfile, selectedRegion)
+		taskID, service := task.ID(), task.ServiceName()
+		container, err := ecs.SelectECSContainer(cluster, taskID, selectedProfile, selectedRegion)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Cluster: %s, Service: %s, Task ID: %s, Runtime ID: %s, Container: %s\n", cluster, service, taskID, runtimeID, containerName)
+		fmt.Printf("Cluster: %s, Service: %s, Task ID: %s, Runtime ID: %s, Container: %s\n", cluster, utils.ValueOrDash(service), taskID, container.RuntimeID, container.Name)
 
-		err = ecs.StartECSSSMSession(selectedProfile, cluster, taskID, runtimeID, dbHost, selectedRegion, dbPort)
+		err = ecs.StartECSSSMSession(selectedProfile, cluster, taskID, container, dbHost, selectedRegion, dbPort)
 		if err != nil {
 			return err
 		}
@@ -76,4 +82,29 @@ func ExecutePortForwarding() error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// Prompts for the EICE tunnel target (the RDS host or the instance itself) and starts the tunnel
+func startEC2EICESession(instanceID, profile, dbHost, region string, dbPort int) error {
+	rdsTarget := fmt.Sprintf("RDS (%s:%d)", dbHost, dbPort)
+	target, err := utils.PromptSelection([]string{rdsTarget, "EC2 instance itself"}, "Tunnel Target")
+	if err != nil {
+		return err
+	}
+	if target == rdsTarget {
+		return ec2.StartEC2EICESession(instanceID, profile, dbHost, region, dbPort)
+	}
+
+	input, err := utils.PromptInput("Enter the instance port to forward to", func(input string) error {
+		port, err := strconv.Atoi(input)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("port must be a number between 1 and 65535")
+		}
+		return nil
+	}, "22")
+	if err != nil {
+		return err
+	}
+	instancePort, _ := strconv.Atoi(input)
+	return ec2.StartEC2EICESession(instanceID, profile, "", region, instancePort)
+}
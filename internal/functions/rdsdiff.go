@@ -0,0 +1,169 @@
+package functions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// dbSpec is one side of `infra rds diff`: [[profile:]region:]name
+type dbSpec struct {
+	Profile string
+	Region  string
+	Name    string
+}
+
+// Parses a [[profile:]region:]name spec; the profile and region are left empty when not given
+func parseDBSpec(spec string) (dbSpec, error) {
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		return dbSpec{Name: parts[0]}, nil
+	case 2:
+		return dbSpec{Region: parts[0], Name: parts[1]}, nil
+	case 3:
+		return dbSpec{Profile: parts[0], Region: parts[1], Name: parts[2]}, nil
+	}
+	return dbSpec{}, fmt.Errorf("invalid spec %q; use [[profile:]region:]name", spec)
+}
+
+func (s dbSpec) String() string {
+	return fmt.Sprintf("%s (%s, %s)", s.Name, s.Profile, s.Region)
+}
+
+// Compares two instances, clusters or parameter groups, possibly in different regions or accounts:
+// their configuration, and every parameter that differs between them or from the engine default.
+// The selected profile and region are only prompted for when a spec does not name them.
+func ExecuteRDSDiff(a, b string) error {
+	// Step 1: Parse the specs, logging in for whatever they leave out
+	from, err := parseDBSpec(a)
+	if err != nil {
+		return err
+	}
+	to, err := parseDBSpec(b)
+	if err != nil {
+		return err
+	}
+	if from.Profile == "" || from.Region == "" || to.Profile == "" || to.Region == "" {
+		selectedProfile, selectedRegion, err := utils.Login()
+		if err != nil {
+			return err
+		}
+		for _, spec := range []*dbSpec{&from, &to} {
+			if spec.Profile == "" {
+				spec.Profile = selectedProfile
+			}
+			if spec.Region == "" {
+				spec.Region = selectedRegion
+			}
+		}
+	}
+
+	// Step 2: Resolve both sides
+	fromResource, err := rds.ResolveDBResource(from.Name, from.Profile, from.Region)
+	if err != nil {
+		return err
+	}
+	toResource, err := rds.ResolveDBResource(to.Name, to.Profile, to.Region)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("--- %s %s\n+++ %s %s\n", fromResource.Kind, from, toResource.Kind, to)
+
+	// Step 3: Compare the configuration
+	fmt.Println("\nConfiguration:")
+	if fromResource.Kind != toResource.Kind {
+		fmt.Printf("  Comparing a %s with a %s; settings only one of them has show as removed or added.\n", fromResource.Kind, toResource.Kind)
+	}
+	changes := diffFields(fromResource.Fields, toResource.Fields)
+	if len(changes) == 0 {
+		fmt.Println("  No differences.")
+	}
+	for _, change := range changes {
+		fmt.Println("  " + change)
+	}
+
+	// Step 4: Compare the parameters of the groups both sides have
+	if fromResource.ClusterParameterGroup != "" && toResource.ClusterParameterGroup != "" {
+		fromParams, err := rds.GetDBClusterParameters(fromResource.ClusterParameterGroup, from.Profile, from.Region)
+		if err != nil {
+			return err
+		}
+		toParams, err := rds.GetDBClusterParameters(toResource.ClusterParameterGroup, to.Profile, to.Region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nCluster parameters (%s vs %s):\n", fromResource.ClusterParameterGroup, toResource.ClusterParameterGroup)
+		printParameterDiff(fromParams, toParams)
+	}
+	if fromResource.ParameterGroup != "" && toResource.ParameterGroup != "" {
+		fromParams, err := rds.GetDBParameters(fromResource.ParameterGroup, from.Profile, from.Region)
+		if err != nil {
+			return err
+		}
+		toParams, err := rds.GetDBParameters(toResource.ParameterGroup, to.Profile, to.Region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nParameters (%s vs %s):\n", fromResource.ParameterGroup, toResource.ParameterGroup)
+		printParameterDiff(fromParams, toParams)
+	}
+	return nil
+}
+
+// Prints the parameters only one side has (-, +), those whose values differ (~), and those equal
+// on both sides but changed from the engine default on either (=). Default values are marked.
+func printParameterDiff(from, to []rds.Parameter) {
+	fromByName := map[string]rds.Parameter{}
+	toByName := map[string]rds.Parameter{}
+	names := map[string]bool{}
+	for _, p := range from {
+		fromByName[p.ParameterName] = p
+		names[p.ParameterName] = true
+	}
+	for _, p := range to {
+		toByName[p.ParameterName] = p
+		names[p.ParameterName] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	display := func(p rds.Parameter) string {
+		value := p.ParameterValue
+		if value == "" {
+			value = "<unset>"
+		}
+		if !p.IsModified() {
+			value += " (default)"
+		}
+		return value
+	}
+
+	count := 0
+	for _, name := range sorted {
+		f, inFrom := fromByName[name]
+		t, inTo := toByName[name]
+		switch {
+		case !inTo:
+			fmt.Printf("  - %s: %s\n", name, display(f))
+		case !inFrom:
+			fmt.Printf("  + %s: %s\n", name, display(t))
+		case f.ParameterValue != t.ParameterValue:
+			fmt.Printf("  ~ %s: %s -> %s\n", name, display(f), display(t))
+		case f.IsModified() || t.IsModified():
+			fmt.Printf("  = %s: %s | %s\n", name, display(f), display(t))
+		default:
+			continue
+		}
+		count++
+	}
+	if count == 0 {
+		fmt.Println("  No differences, and every parameter is at its engine default.")
+	}
+}
@@ -0,0 +1,142 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// Prints the top SQL statements of a selected instance by DB load over the last window, with the
+// wait events each spends its load on, and the top wait events and users. Statements are shortened
+// to one line unless full is set.
+func ExecuteRDSInsightsTop(window time.Duration, limit int, full bool) error {
+	if limit < 1 || limit > 25 {
+		return fmt.Errorf("--limit must be between 1 and 25")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the instance
+	instance, err := rds.SelectDBInstance(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if !instance.PerformanceInsightsEnabled {
+		return fmt.Errorf("Performance Insights is not enabled on %s", instance.DBInstanceIdentifier)
+	}
+
+	// Step 3: Fetch the load by wait event, SQL (partitioned by wait event) and user
+	end := time.Now()
+	start := end.Add(-window)
+	query := func(group, partitionBy string, limit int) (*rds.InsightsTop, error) {
+		return rds.GetInsightsTop(instance.DbiResourceID, group, partitionBy, limit, start, end, selectedProfile, selectedRegion)
+	}
+	// The wait events are fetched at the maximum limit so their sum is the instance's total load
+	waits, err := query(rds.InsightsGroupWaitEvent, "", 25)
+	if err != nil {
+		return err
+	}
+	statements, err := query(rds.InsightsGroupSQL, rds.InsightsGroupWaitEvent, limit)
+	if err != nil {
+		return err
+	}
+	users, err := query(rds.InsightsGroupUser, "", limit)
+	if err != nil {
+		return err
+	}
+
+	total := 0.0
+	for _, key := range waits.Keys {
+		total += key.Total
+	}
+	share := func(load float64) string {
+		if total == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%%", load*100/total)
+	}
+
+	// Step 4: Print the report
+	fmt.Printf("Performance Insights for %s (%s %s) from %s to %s\n", instance.DBInstanceIdentifier, instance.Engine,
+		instance.DBInstanceClass, start.Format("2006-01-02 15:04"), end.Format("15:04"))
+	fmt.Printf("Average DB load: %.2f active sessions\n", total)
+	if total == 0 {
+		fmt.Println("No load was recorded in this window.")
+		return nil
+	}
+
+	fmt.Println("\nTop SQL by DB load:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tLOAD\tSHARE\tWAITS\tSQL ID\tSTATEMENT")
+	for i, key := range statements.Keys {
+		statement := key.Dimensions[rds.InsightsSQLStatement]
+		if !full {
+			statement = shortenStatement(statement, 100)
+		}
+		fmt.Fprintf(w, "%d\t%.2f\t%s\t%s\t%s\t%s\n", i+1, key.Total, share(key.Total),
+			topWaits(key, statements.PartitionKeys, 3), utils.ValueOrDash(key.Dimensions[rds.InsightsSQLID]), statement)
+	}
+	w.Flush()
+
+	fmt.Println("\nTop wait events:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WAIT EVENT\tTYPE\tLOAD\tSHARE")
+	for i, key := range waits.Keys {
+		if i == limit {
+			break
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\n", key.Dimensions[rds.InsightsWaitName], key.Dimensions[rds.InsightsWaitType], key.Total, share(key.Total))
+	}
+	w.Flush()
+
+	fmt.Println("\nTop users:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tLOAD\tSHARE")
+	for _, key := range users.Keys {
+		fmt.Fprintf(w, "%s\t%.2f\t%s\n", utils.ValueOrDash(key.Dimensions[rds.InsightsUserName]), key.Total, share(key.Total))
+	}
+	return w.Flush()
+}
+
+// Returns the wait events a key spends most of its load on, with their share of its load
+func topWaits(key rds.InsightsKey, partitionKeys []map[string]string, n int) string {
+	type wait struct {
+		name string
+		load float64
+	}
+	var waits []wait
+	for i, load := range key.Partitions {
+		if i < len(partitionKeys) && load > 0 {
+			waits = append(waits, wait{partitionKeys[i][rds.InsightsWaitName], load})
+		}
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i].load > waits[j].load })
+
+	var parts []string
+	for i, w := range waits {
+		if i == n || key.Total == 0 {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", w.name, w.load*100/key.Total))
+	}
+	return utils.ValueOrDash(strings.Join(parts, ", "))
+}
+
+// Collapses a statement onto one line and cuts it to at most max characters
+func shortenStatement(statement string, max int) string {
+	statement = strings.Join(strings.Fields(statement), " ")
+	if runes := []rune(statement); len(runes) > max {
+		return string(runes[:max-3]) + "..."
+	}
+	return statement
+}
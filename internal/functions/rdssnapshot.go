@@ -0,0 +1,305 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"raid/infra/internal/aws"
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// Takes a manual snapshot of a selected instance or cluster, named <source>-<label>-<yyyymmdd-hhmmss>,
+// and unless noWait is set waits until it is available
+func ExecuteRDSSnapshotCreate(label string, noWait bool, timeout time.Duration) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the instance or cluster
+	target, err := rds.SelectDBTarget(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Start the snapshot
+	snapshotID := rds.SnapshotName(target.Identifier, label, time.Now())
+	if _, err := rds.CreateSnapshot(target, snapshotID, selectedProfile, selectedRegion); err != nil {
+		return err
+	}
+	fmt.Printf("Creating snapshot %s of %s\n", snapshotID, target)
+	if noWait {
+		return nil
+	}
+
+	// Step 4: Wait for it to become available
+	if err := rds.WaitForSnapshot(target.IsCluster, snapshotID, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot %s is available.\n", snapshotID)
+	return nil
+}
+
+// Lists the manual snapshots of a selected instance or cluster, newest first
+func ExecuteRDSSnapshotList() error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the instance or cluster
+	target, err := rds.SelectDBTarget(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Print its snapshots
+	snapshots, err := rds.ListManualSnapshots(target, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No manual snapshots of %s.\n", target)
+		return nil
+	}
+	printSnapshots(snapshots)
+	return nil
+}
+
+// Prints snapshots as a table
+func printSnapshots(snapshots []rds.Snapshot) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tCREATED\tSTATUS\tSIZE\tENCRYPTED")
+	for _, s := range snapshots {
+		status := s.Status
+		if s.Status == "creating" {
+			status = fmt.Sprintf("creating (%d%%)", s.PercentProgress)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d GiB\t%t\n", s.Identifier, s.CreateTime.Local().Format("2006-01-02 15:04"), status, s.AllocatedStorage, s.Encrypted)
+	}
+	w.Flush()
+}
+
+// Deletes the manual snapshots of a selected instance or cluster beyond the newest keep, or older
+// than olderThan, after confirmation. At least one of keep and olderThan must be set.
+func ExecuteRDSSnapshotPrune(keep int, olderThan time.Duration, autoApprove bool) error {
+	if keep < 0 && olderThan <= 0 {
+		return fmt.Errorf("at least one of --keep and --older-than is required")
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the instance or cluster
+	target, err := rds.SelectDBTarget(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Pick the snapshots to delete; snapshots still being created or copied are left alone
+	snapshots, err := rds.ListManualSnapshots(target, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var prune []rds.Snapshot
+	for i, s := range snapshots {
+		if s.Status != "available" {
+			continue
+		}
+		if (keep >= 0 && i >= keep) || (olderThan > 0 && s.CreateTime.Before(cutoff)) {
+			prune = append(prune, s)
+		}
+	}
+	if len(prune) == 0 {
+		fmt.Printf("No snapshots of %s to prune.\n", target)
+		return nil
+	}
+
+	fmt.Printf("%d of %d manual snapshot(s) of %s will be deleted:\n", len(prune), len(snapshots), target)
+	printSnapshots(prune)
+	if !autoApprove && !utils.ConfirmPrompt("Delete these snapshots? (Y/N)") {
+		return fmt.Errorf("prune cancelled")
+	}
+
+	// Step 4: Delete them
+	for i := range prune {
+		if err := rds.DeleteSnapshot(&prune[i], selectedProfile, selectedRegion); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", prune[i].Identifier)
+	}
+	return nil
+}
+
+// Copies a manual snapshot to another region and/or account, re-encrypting it with kmsKeyID.
+// Copies to another account share the snapshot with that account first and are made with toProfile.
+func ExecuteRDSSnapshotCopy(toRegion, toProfile, kmsKeyID string, noWait bool, timeout time.Duration) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+	if toRegion == "" {
+		toRegion = selectedRegion
+	}
+	if toProfile == "" {
+		toProfile = selectedProfile
+	}
+	if toRegion == selectedRegion && toProfile == selectedProfile {
+		return fmt.Errorf("--to-region or --to-profile must name a different region or account")
+	}
+
+	// Step 2: Select the snapshot
+	target, err := rds.SelectDBTarget(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	snapshot, err := rds.SelectSnapshot(target, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status != "available" {
+		return fmt.Errorf("snapshot %s is %s; only available snapshots can be copied", snapshot.Identifier, snapshot.Status)
+	}
+
+	// Step 3: Share the snapshot if it is copied to another account
+	sourceAccount, err := accountID(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	targetAccount, err := accountID(toProfile, toRegion)
+	if err != nil {
+		return err
+	}
+	crossAccount := sourceAccount != targetAccount
+	if snapshot.Encrypted && kmsKeyID == "" && (crossAccount || toRegion != selectedRegion) {
+		return fmt.Errorf("snapshot %s is encrypted; --kms-key must name a key in %s of account %s to re-encrypt it with", snapshot.Identifier, toRegion, targetAccount)
+	}
+	if crossAccount {
+		if snapshot.Encrypted {
+			managed, err := aws.IsAWSManagedKey(selectedProfile, selectedRegion, snapshot.KmsKeyID)
+			if err != nil {
+				return err
+			}
+			if managed {
+				return fmt.Errorf("snapshot %s is encrypted with an AWS managed key (such as aws/rds), which cannot be shared with other accounts; copy it within this account with a customer managed key first", snapshot.Identifier)
+			}
+		}
+		if err := rds.ShareSnapshot(snapshot, targetAccount, selectedProfile, selectedRegion); err != nil {
+			return err
+		}
+		fmt.Printf("Shared %s with account %s\n", snapshot.Identifier, targetAccount)
+	}
+
+	// Step 4: Copy it from the destination
+	copied, err := rds.CopySnapshot(snapshot, selectedRegion, snapshot.Identifier, kmsKeyID, toProfile, toRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Copying %s to %s in %s (account %s)\n", snapshot.Identifier, copied.Identifier, toRegion, targetAccount)
+	if noWait {
+		return nil
+	}
+
+	// Step 5: Wait for the copy to become available
+	if err := rds.WaitForSnapshot(copied.IsCluster, copied.Identifier, toProfile, toRegion, timeout); err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot %s is available in %s.\n", copied.Identifier, toRegion)
+	return nil
+}
+
+// Returns the account a profile belongs to
+func accountID(profile, region string) (string, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return "", err
+	}
+	return aws.GetAWSAccountID(cfg)
+}
+
+// Restores a manual snapshot to a new instance, or a new Aurora cluster with one writer, in the
+// subnet group and security groups of the snapshot's source and with its instance class
+func ExecuteRDSSnapshotRestore(identifier string, noWait bool, timeout time.Duration) error {
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Select the source and one of its snapshots
+	target, err := rds.SelectDBTarget(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	snapshot, err := rds.SelectSnapshot(target, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status != "available" {
+		return fmt.Errorf("snapshot %s is %s; only available snapshots can be restored", snapshot.Identifier, snapshot.Status)
+	}
+
+	// Step 3: Copy the network settings and instance class from the source
+	settings, err := rds.RestoreSettingsFrom(target, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	if identifier == "" {
+		identifier, err = utils.PromptInput("New identifier", func(input string) error {
+			if input == "" {
+				return fmt.Errorf("identifier is required")
+			}
+			return nil
+		}, rds.SnapshotName(target.Identifier, "restore", time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+	settings.Identifier = identifier
+
+	fmt.Printf("Restoring %s as %s (%s, subnet group %s, security groups %s)\n", snapshot.Identifier, identifier,
+		settings.InstanceClass, settings.SubnetGroup, strings.Join(settings.SecurityGroupIDs, ", "))
+
+	// Step 4: Restore it and wait for the new instance or cluster
+	if !target.IsCluster {
+		if err := rds.RestoreDBInstance(snapshot, *settings, selectedProfile, selectedRegion); err != nil {
+			return err
+		}
+		if noWait {
+			return nil
+		}
+		if err := rds.WaitForDBInstanceAvailable(identifier, selectedProfile, selectedRegion, timeout); err != nil {
+			return err
+		}
+		fmt.Printf("RDS instance %s is available.\n", identifier)
+		return nil
+	}
+
+	writer, err := rds.RestoreDBCluster(snapshot, *settings, selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added writer instance %s\n", writer)
+	if noWait {
+		return nil
+	}
+	if err := rds.WaitForDBClusterAvailable(identifier, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+	if err := rds.WaitForDBInstanceAvailable(writer, selectedProfile, selectedRegion, timeout); err != nil {
+		return err
+	}
+	fmt.Printf("Aurora cluster %s is available.\n", identifier)
+	return nil
+}
@@ -0,0 +1,193 @@
+package functions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"raid/infra/internal/rds"
+	"raid/infra/internal/utils"
+)
+
+// rdsStatus is one instance or cluster in the `infra rds status` report
+type rdsStatus struct {
+	Identifier                 string     `json:"identifier"`
+	Type                       string     `json:"type"`
+	Cluster                    string     `json:"cluster,omitempty"`
+	Status                     string     `json:"status"`
+	InstanceClass              string     `json:"instanceClass,omitempty"`
+	Engine                     string     `json:"engine"`
+	EngineVersion              string     `json:"engineVersion"`
+	NewerMinorVersion          string     `json:"newerMinorVersion,omitempty"`
+	PendingMaintenance         []string   `json:"pendingMaintenance"`
+	MaintenanceWindow          string     `json:"maintenanceWindow"`
+	BackupWindow               string     `json:"backupWindow"`
+	BackupRetentionDays        int        `json:"backupRetentionDays"`
+	DeletionProtection         bool       `json:"deletionProtection"`
+	MultiAZ                    bool       `json:"multiAZ"`
+	CACertificate              string     `json:"caCertificate,omitempty"`
+	CertificateValidTill       *time.Time `json:"certificateValidTill,omitempty"`
+	DaysUntilCertificateExpiry *int       `json:"daysUntilCertificateExpiry,omitempty"`
+}
+
+// Reports the engine versions, available minor upgrades, pending maintenance, maintenance and
+// backup windows, backup retention, deletion protection, Multi-AZ and server certificate expiry
+// of every RDS instance and Aurora cluster in the region, as a table, JSON or CSV
+func ExecuteRDSStatus(format, file string) error {
+	if err := utils.ValidateOutputFormat(format); err != nil {
+		return err
+	}
+
+	// Step 1: Login to AWS
+	selectedProfile, selectedRegion, err := utils.Login()
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Fetch the fleet and its pending maintenance
+	clusters, err := rds.DescribeDBClusters(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	instances, err := rds.DescribeDBInstances(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+	pending, err := rds.GetPendingMaintenanceActions(selectedProfile, selectedRegion)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Build the report, with cluster members listed under their cluster
+	newerMinor := map[string]string{}
+	newerMinorVersion := func(engine, version string) string {
+		key := engine + " " + version
+		if v, ok := newerMinor[key]; ok {
+			return v
+		}
+		v, err := rds.GetNewerMinorVersion(engine, version, selectedProfile, selectedRegion)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+		newerMinor[key] = v
+		return v
+	}
+
+	members := map[string][]rds.DBInstance{}
+	var standalone []rds.DBInstance
+	for _, instance := range instances {
+		if instance.DBClusterIdentifier != "" {
+			members[instance.DBClusterIdentifier] = append(members[instance.DBClusterIdentifier], instance)
+		} else {
+			standalone = append(standalone, instance)
+		}
+	}
+
+	var report []rdsStatus
+	for _, cluster := range clusters {
+		report = append(report, rdsStatus{
+			Identifier:          cluster.DBClusterIdentifier,
+			Type:                "cluster",
+			Status:              cluster.Status,
+			Engine:              cluster.Engine,
+			EngineVersion:       cluster.EngineVersion,
+			NewerMinorVersion:   newerMinorVersion(cluster.Engine, cluster.EngineVersion),
+			PendingMaintenance:  maintenanceSummaries(pending[cluster.DBClusterArn]),
+			MaintenanceWindow:   cluster.PreferredMaintenanceWindow,
+			BackupWindow:        cluster.PreferredBackupWindow,
+			BackupRetentionDays: cluster.BackupRetentionPeriod,
+			DeletionProtection:  cluster.DeletionProtection,
+			MultiAZ:             cluster.MultiAZ,
+		})
+		for _, instance := range members[cluster.DBClusterIdentifier] {
+			// Backups and deletion protection of Aurora members are managed by their cluster
+			status := instanceStatus(instance, pending)
+			status.BackupRetentionDays = cluster.BackupRetentionPeriod
+			status.BackupWindow = cluster.PreferredBackupWindow
+			status.DeletionProtection = cluster.DeletionProtection
+			report = append(report, status)
+		}
+	}
+	for _, instance := range standalone {
+		status := instanceStatus(instance, pending)
+		status.NewerMinorVersion = newerMinorVersion(instance.Engine, instance.EngineVersion)
+		report = append(report, status)
+	}
+	if len(report) == 0 {
+		fmt.Fprintf(os.Stderr, "No RDS instances or clusters in %s.\n", selectedRegion)
+		return nil
+	}
+
+	// Step 4: Write the report
+	var out io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", file, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	header := []string{"IDENTIFIER", "TYPE", "CLUSTER", "STATUS", "CLASS", "ENGINE", "VERSION", "NEWER MINOR",
+		"PENDING MAINTENANCE", "MAINTENANCE WINDOW", "BACKUP WINDOW", "BACKUP DAYS", "DELETION PROTECTION", "MULTI-AZ",
+		"CA", "CERT EXPIRES", "DAYS LEFT"}
+	rows := make([][]string, 0, len(report))
+	for _, s := range report {
+		certificateExpiry, daysLeft := "", ""
+		if s.CertificateValidTill != nil {
+			certificateExpiry = s.CertificateValidTill.Format("2006-01-02")
+			daysLeft = strconv.Itoa(*s.DaysUntilCertificateExpiry)
+		}
+		rows = append(rows, []string{s.Identifier, s.Type, s.Cluster, s.Status, s.InstanceClass, s.Engine, s.EngineVersion,
+			s.NewerMinorVersion, strings.Join(s.PendingMaintenance, "; "), s.MaintenanceWindow, s.BackupWindow,
+			strconv.Itoa(s.BackupRetentionDays), strconv.FormatBool(s.DeletionProtection), strconv.FormatBool(s.MultiAZ),
+			s.CACertificate, certificateExpiry, daysLeft})
+	}
+	if err := utils.WriteRecords(out, format, header, rows, report); err != nil {
+		return err
+	}
+	if file != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %d instance(s) and cluster(s) to %s\n", len(report), file)
+	}
+	return nil
+}
+
+// Returns the report entry of an instance. Its newer minor version is left to the caller, as
+// Aurora members are upgraded through their cluster.
+func instanceStatus(instance rds.DBInstance, pending map[string][]rds.PendingMaintenanceAction) rdsStatus {
+	status := rdsStatus{
+		Identifier:          instance.DBInstanceIdentifier,
+		Type:                "instance",
+		Cluster:             instance.DBClusterIdentifier,
+		Status:              instance.DBInstanceStatus,
+		InstanceClass:       instance.DBInstanceClass,
+		Engine:              instance.Engine,
+		EngineVersion:       instance.EngineVersion,
+		PendingMaintenance:  maintenanceSummaries(pending[instance.DBInstanceArn]),
+		MaintenanceWindow:   instance.PreferredMaintenanceWindow,
+		BackupWindow:        instance.PreferredBackupWindow,
+		BackupRetentionDays: instance.BackupRetentionPeriod,
+		DeletionProtection:  instance.DeletionProtection,
+		MultiAZ:             instance.MultiAZ,
+		CACertificate:       instance.CACertificateIdentifier,
+	}
+	if validTill := instance.CertificateDetails.ValidTill; !validTill.IsZero() {
+		days := int(time.Until(validTill).Hours() / 24)
+		status.CertificateValidTill = &validTill
+		status.DaysUntilCertificateExpiry = &days
+	}
+	return status
+}
+
+// Returns the summaries of maintenance actions, never nil so JSON shows an empty list
+func maintenanceSummaries(actions []rds.PendingMaintenanceAction) []string {
+	summaries := []string{}
+	for _, action := range actions {
+		summaries = append(summaries, action.Summary())
+	}
+	return summaries
+}
@@ -0,0 +1,481 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	rdssdk "github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+
+	"raid/infra/internal/utils"
+)
+
+// DBInstance is the subset of an RDS instance description used by infra
+type DBInstance struct {
+	DBInstanceIdentifier             string
+	DBInstanceArn                    string
+	DBInstanceClass                  string
+	DBInstanceStatus                 string
+	DBClusterIdentifier              string
+	Engine                           string
+	EngineVersion                    string
+	Endpoint                         Endpoint
+	DBSubnetGroup                    DBSubnetGroup
+	VpcSecurityGroups                []VpcSecurityGroup
+	StorageEncrypted                 bool
+	KmsKeyID                         string
+	MultiAZ                          bool
+	BackupRetentionPeriod            int
+	DeletionProtection               bool
+	AutoMinorVersionUpgrade          bool
+	PreferredMaintenanceWindow       string
+	PreferredBackupWindow            string
+	CACertificateIdentifier          string
+	CertificateDetails               CertificateDetails
+	MasterUsername                   string
+	MasterUserSecret                 *MasterUserSecret
+	DbiResourceID                    string
+	PerformanceInsightsEnabled       bool
+	AllocatedStorage                 int
+	MaxAllocatedStorage              int
+	StorageType                      string
+	Iops                             int
+	StorageThroughput                int
+	IAMDatabaseAuthenticationEnabled bool
+	DBParameterGroups                []DBParameterGroupStatus
+}
+
+// DBCluster is the subset of an Aurora cluster description used by infra
+type DBCluster struct {
+	DBClusterIdentifier              string
+	DBClusterArn                     string
+	Status                           string
+	Engine                           string
+	EngineVersion                    string
+	Endpoint                         string
+	Port                             int
+	DBSubnetGroup                    string
+	VpcSecurityGroups                []VpcSecurityGroup
+	DBClusterMembers                 []DBClusterMember
+	StorageEncrypted                 bool
+	KmsKeyID                         string
+	MultiAZ                          bool
+	BackupRetentionPeriod            int
+	DeletionProtection               bool
+	PreferredMaintenanceWindow       string
+	PreferredBackupWindow            string
+	MasterUsername                   string
+	MasterUserSecret                 *MasterUserSecret
+	HttpEndpointEnabled              bool
+	StorageType                      string
+	IAMDatabaseAuthenticationEnabled bool
+	DBClusterParameterGroup          string
+	ServerlessV2ScalingConfiguration *ServerlessV2ScalingConfiguration
+}
+
+// Endpoint is the address and port an RDS instance listens on
+type Endpoint struct {
+	Address string
+	Port    int
+}
+
+// CertificateDetails is the CA and expiry of the server certificate an RDS instance presents
+type CertificateDetails struct {
+	CAIdentifier string
+	ValidTill    time.Time
+}
+
+// MasterUserSecret is the Secrets Manager secret RDS manages the master user's password in
+type MasterUserSecret struct {
+	SecretArn string
+}
+
+// DBParameterGroupStatus is a parameter group an RDS instance uses, and whether its changes are applied
+type DBParameterGroupStatus struct {
+	DBParameterGroupName string
+	ParameterApplyStatus string
+}
+
+// ServerlessV2ScalingConfiguration is the capacity range of an Aurora Serverless v2 cluster, in ACUs
+type ServerlessV2ScalingConfiguration struct {
+	MinCapacity float64
+	MaxCapacity float64
+}
+
+// DBSubnetGroup is the subnet group an RDS instance is placed in
+type DBSubnetGroup struct {
+	DBSubnetGroupName string
+}
+
+// VpcSecurityGroup is a security group attached to an RDS instance or cluster
+type VpcSecurityGroup struct {
+	VpcSecurityGroupID string
+	Status             string
+}
+
+// DBClusterMember is an instance in an Aurora cluster
+type DBClusterMember struct {
+	DBInstanceIdentifier string
+	IsClusterWriter      bool
+}
+
+// DBTarget is an RDS instance or an Aurora cluster chosen by the user
+type DBTarget struct {
+	Identifier string
+	IsCluster  bool
+}
+
+// Returns a description of the target for display
+func (t DBTarget) String() string {
+	if t.IsCluster {
+		return "Aurora cluster " + t.Identifier
+	}
+	return "RDS instance " + t.Identifier
+}
+
+// Returns the IDs of the security groups
+func securityGroupIDs(groups []VpcSecurityGroup) []string {
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.VpcSecurityGroupID)
+	}
+	return ids
+}
+
+// Fetches every RDS instance in the region, including Aurora cluster members
+func DescribeDBInstances(profile, region string) ([]DBInstance, error) {
+	instances, err := describeDBInstances(profile, region, &rdssdk.DescribeDBInstancesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch RDS instances: %v", err)
+	}
+	return instances, nil
+}
+
+// Fetches an RDS instance
+func DescribeDBInstance(identifier, profile, region string) (*DBInstance, error) {
+	instances, err := describeDBInstances(profile, region, &rdssdk.DescribeDBInstancesInput{DBInstanceIdentifier: awssdk.String(identifier)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe RDS instance: %w", err)
+	}
+	if len(instances) == 0 {
+		return nil, &rdstypes.DBInstanceNotFoundFault{Message: awssdk.String(fmt.Sprintf("RDS instance %s not found", identifier))}
+	}
+	return &instances[0], nil
+}
+
+// Fetches every RDS instance matching a describe-db-instances request
+func describeDBInstances(profile, region string, input *rdssdk.DescribeDBInstancesInput) ([]DBInstance, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var instances []DBInstance
+	paginator := rdssdk.NewDescribeDBInstancesPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range page.DBInstances {
+			instances = append(instances, instanceFromSDK(instance))
+		}
+	}
+	return instances, nil
+}
+
+// Fetches every Aurora (and Multi-AZ DB) cluster in the region
+func DescribeDBClusters(profile, region string) ([]DBCluster, error) {
+	clusters, err := describeDBClusters(profile, region, &rdssdk.DescribeDBClustersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch RDS clusters: %v", err)
+	}
+	return clusters, nil
+}
+
+// Fetches an Aurora cluster
+func DescribeDBCluster(identifier, profile, region string) (*DBCluster, error) {
+	clusters, err := describeDBClusters(profile, region, &rdssdk.DescribeDBClustersInput{DBClusterIdentifier: awssdk.String(identifier)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe RDS cluster: %w", err)
+	}
+	if len(clusters) == 0 {
+		return nil, &rdstypes.DBClusterNotFoundFault{Message: awssdk.String(fmt.Sprintf("RDS cluster %s not found", identifier))}
+	}
+	return &clusters[0], nil
+}
+
+// Fetches every cluster matching a describe-db-clusters request
+func describeDBClusters(profile, region string, input *rdssdk.DescribeDBClustersInput) ([]DBCluster, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []DBCluster
+	paginator := rdssdk.NewDescribeDBClustersPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range page.DBClusters {
+			clusters = append(clusters, clusterFromSDK(cluster))
+		}
+	}
+	return clusters, nil
+}
+
+func securityGroupsFromSDK(groups []rdstypes.VpcSecurityGroupMembership) []VpcSecurityGroup {
+	var result []VpcSecurityGroup
+	for _, g := range groups {
+		result = append(result, VpcSecurityGroup{VpcSecurityGroupID: awssdk.ToString(g.VpcSecurityGroupId), Status: awssdk.ToString(g.Status)})
+	}
+	return result
+}
+
+func masterUserSecretFromSDK(secret *rdstypes.MasterUserSecret) *MasterUserSecret {
+	if secret == nil {
+		return nil
+	}
+	return &MasterUserSecret{SecretArn: awssdk.ToString(secret.SecretArn)}
+}
+
+func instanceFromSDK(i rdstypes.DBInstance) DBInstance {
+	instance := DBInstance{
+		DBInstanceIdentifier:             awssdk.ToString(i.DBInstanceIdentifier),
+		DBInstanceArn:                    awssdk.ToString(i.DBInstanceArn),
+		DBInstanceClass:                  awssdk.ToString(i.DBInstanceClass),
+		DBInstanceStatus:                 awssdk.ToString(i.DBInstanceStatus),
+		DBClusterIdentifier:              awssdk.ToString(i.DBClusterIdentifier),
+		Engine:                           awssdk.ToString(i.Engine),
+		EngineVersion:                    awssdk.ToString(i.EngineVersion),
+		VpcSecurityGroups:                securityGroupsFromSDK(i.VpcSecurityGroups),
+		StorageEncrypted:                 awssdk.ToBool(i.StorageEncrypted),
+		KmsKeyID:                         awssdk.ToString(i.KmsKeyId),
+		MultiAZ:                          awssdk.ToBool(i.MultiAZ),
+		BackupRetentionPeriod:            int(awssdk.ToInt32(i.BackupRetentionPeriod)),
+		DeletionProtection:               awssdk.ToBool(i.DeletionProtection),
+		AutoMinorVersionUpgrade:          awssdk.ToBool(i.AutoMinorVersionUpgrade),
+		PreferredMaintenanceWindow:       awssdk.ToString(i.PreferredMaintenanceWindow),
+		PreferredBackupWindow:            awssdk.ToString(i.PreferredBackupWindow),
+		CACertificateIdentifier:          awssdk.ToString(i.CACertificateIdentifier),
+		MasterUsername:                   awssdk.ToString(i.MasterUsername),
+		MasterUserSecret:                 masterUserSecretFromSDK(i.MasterUserSecret),
+		DbiResourceID:                    awssdk.ToString(i.DbiResourceId),
+		PerformanceInsightsEnabled:       awssdk.ToBool(i.PerformanceInsightsEnabled),
+		AllocatedStorage:                 int(awssdk.ToInt32(i.AllocatedStorage)),
+		MaxAllocatedStorage:              int(awssdk.ToInt32(i.MaxAllocatedStorage)),
+		StorageType:                      awssdk.ToString(i.StorageType),
+		Iops:                             int(awssdk.ToInt32(i.Iops)),
+		StorageThroughput:                int(awssdk.ToInt32(i.StorageThroughput)),
+		IAMDatabaseAuthenticationEnabled: awssdk.ToBool(i.IAMDatabaseAuthenticationEnabled),
+	}
+	if i.Endpoint != nil {
+		instance.Endpoint = Endpoint{Address: awssdk.ToString(i.Endpoint.Address), Port: int(awssdk.ToInt32(i.Endpoint.Port))}
+	}
+	if i.DBSubnetGroup != nil {
+		instance.DBSubnetGroup = DBSubnetGroup{DBSubnetGroupName: awssdk.ToString(i.DBSubnetGroup.DBSubnetGroupName)}
+	}
+	if i.CertificateDetails != nil {
+		instance.CertificateDetails = CertificateDetails{
+			CAIdentifier: awssdk.ToString(i.CertificateDetails.CAIdentifier),
+			ValidTill:    awssdk.ToTime(i.CertificateDetails.ValidTill),
+		}
+	}
+	for _, group := range i.DBParameterGroups {
+		instance.DBParameterGroups = append(instance.DBParameterGroups, DBParameterGroupStatus{
+			DBParameterGroupName: awssdk.ToString(group.DBParameterGroupName),
+			ParameterApplyStatus: awssdk.ToString(group.ParameterApplyStatus),
+		})
+	}
+	return instance
+}
+
+func clusterFromSDK(c rdstypes.DBCluster) DBCluster {
+	cluster := DBCluster{
+		DBClusterIdentifier:              awssdk.ToString(c.DBClusterIdentifier),
+		DBClusterArn:                     awssdk.ToString(c.DBClusterArn),
+		Status:                           awssdk.ToString(c.Status),
+		Engine:                           awssdk.ToString(c.Engine),
+		EngineVersion:                    awssdk.ToString(c.EngineVersion),
+		Endpoint:                         awssdk.ToString(c.Endpoint),
+		Port:                             int(awssdk.ToInt32(c.Port)),
+		DBSubnetGroup:                    awssdk.ToString(c.DBSubnetGroup),
+		VpcSecurityGroups:                securityGroupsFromSDK(c.VpcSecurityGroups),
+		StorageEncrypted:                 awssdk.ToBool(c.StorageEncrypted),
+		KmsKeyID:                         awssdk.ToString(c.KmsKeyId),
+		MultiAZ:                          awssdk.ToBool(c.MultiAZ),
+		BackupRetentionPeriod:            int(awssdk.ToInt32(c.BackupRetentionPeriod)),
+		DeletionProtection:               awssdk.ToBool(c.DeletionProtection),
+		PreferredMaintenanceWindow:       awssdk.ToString(c.PreferredMaintenanceWindow),
+		PreferredBackupWindow:            awssdk.ToString(c.PreferredBackupWindow),
+		MasterUsername:                   awssdk.ToString(c.MasterUsername),
+		MasterUserSecret:                 masterUserSecretFromSDK(c.MasterUserSecret),
+		HttpEndpointEnabled:              awssdk.ToBool(c.HttpEndpointEnabled),
+		StorageType:                      awssdk.ToString(c.StorageType),
+		IAMDatabaseAuthenticationEnabled: awssdk.ToBool(c.IAMDatabaseAuthenticationEnabled),
+		DBClusterParameterGroup:          awssdk.ToString(c.DBClusterParameterGroup),
+	}
+	for _, member := range c.DBClusterMembers {
+		cluster.DBClusterMembers = append(cluster.DBClusterMembers, DBClusterMember{
+			DBInstanceIdentifier: awssdk.ToString(member.DBInstanceIdentifier),
+			IsClusterWriter:      awssdk.ToBool(member.IsClusterWriter),
+		})
+	}
+	if scaling := c.ServerlessV2ScalingConfiguration; scaling != nil {
+		cluster.ServerlessV2ScalingConfiguration = &ServerlessV2ScalingConfiguration{
+			MinCapacity: awssdk.ToFloat64(scaling.MinCapacity),
+			MaxCapacity: awssdk.ToFloat64(scaling.MaxCapacity),
+		}
+	}
+	return cluster
+}
+
+// Prompts the user to select an Aurora cluster or a standalone RDS instance
+func SelectDBTarget(profile, region string) (*DBTarget, error) {
+	clusters, err := DescribeDBClusters(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := DescribeDBInstances(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []string
+	for _, c := range clusters {
+		options = append(options, fmt.Sprintf("[Aurora cluster] %s", c.DBClusterIdentifier))
+	}
+	for _, i := range instances {
+		// Cluster members are snapshotted and restored through their cluster
+		if i.DBClusterIdentifier == "" {
+			options = append(options, fmt.Sprintf("[RDS instance] %s", i.DBInstanceIdentifier))
+		}
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("no RDS instances or clusters found")
+	}
+
+	selection, err := utils.PromptSelection(options, "RDS Instance or Cluster")
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(selection, "[Aurora cluster] ") {
+		return &DBTarget{Identifier: strings.TrimPrefix(selection, "[Aurora cluster] "), IsCluster: true}, nil
+	}
+	return &DBTarget{Identifier: strings.TrimPrefix(selection, "[RDS instance] ")}, nil
+}
+
+// DBConnection is where and how to connect to an instance, or to the writer of a cluster
+type DBConnection struct {
+	Identifier     string
+	Host           string
+	Port           int
+	Engine         string
+	MasterUsername string
+	// ARN of the secret RDS manages the master password in, if any
+	MasterUserSecretArn string
+}
+
+// Returns the connection details of an instance, or of the writer endpoint of a cluster
+func GetDBConnection(target *DBTarget, profile, region string) (*DBConnection, error) {
+	if target.IsCluster {
+		cluster, err := DescribeDBCluster(target.Identifier, profile, region)
+		if err != nil {
+			return nil, err
+		}
+		conn := &DBConnection{Identifier: cluster.DBClusterIdentifier, Host: cluster.Endpoint, Port: cluster.Port,
+			Engine: cluster.Engine, MasterUsername: cluster.MasterUsername}
+		if cluster.MasterUserSecret != nil {
+			conn.MasterUserSecretArn = cluster.MasterUserSecret.SecretArn
+		}
+		return conn, nil
+	}
+
+	instance, err := DescribeDBInstance(target.Identifier, profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Endpoint.Address == "" {
+		return nil, fmt.Errorf("RDS instance %s has no endpoint yet (status %s)", instance.DBInstanceIdentifier, instance.DBInstanceStatus)
+	}
+	conn := &DBConnection{Identifier: instance.DBInstanceIdentifier, Host: instance.Endpoint.Address, Port: instance.Endpoint.Port,
+		Engine: instance.Engine, MasterUsername: instance.MasterUsername}
+	if instance.MasterUserSecret != nil {
+		conn.MasterUserSecretArn = instance.MasterUserSecret.SecretArn
+	}
+	return conn, nil
+}
+
+// Returns "postgres" or "mysql" for the engines whose clients infra can drive, or "" for any other
+func EngineFamily(engine string) string {
+	switch engine {
+	case "postgres", "aurora-postgresql":
+		return "postgres"
+	case "mysql", "mariadb", "aurora", "aurora-mysql":
+		return "mysql"
+	}
+	return ""
+}
+
+// Prompts the user to select one of the Aurora clusters with the Data API enabled
+func SelectDataAPICluster(profile, region string) (*DBCluster, error) {
+	clusters, err := DescribeDBClusters(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []DBCluster
+	var options []string
+	for _, c := range clusters {
+		if c.HttpEndpointEnabled {
+			enabled = append(enabled, c)
+			options = append(options, fmt.Sprintf("%s (%s %s)", c.DBClusterIdentifier, c.Engine, c.EngineVersion))
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, fmt.Errorf("no Aurora clusters with the Data API enabled")
+	}
+
+	selection, err := utils.PromptSelection(options, "Aurora Cluster")
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return &enabled[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid cluster selection: %s", selection)
+}
+
+// Prompts the user to select an RDS instance, including members of Aurora clusters
+func SelectDBInstance(profile, region string) (*DBInstance, error) {
+	instances, err := DescribeDBInstances(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no RDS instances found")
+	}
+
+	options := make([]string, len(instances))
+	for i, instance := range instances {
+		options[i] = fmt.Sprintf("%s (%s, %s)", instance.DBInstanceIdentifier, instance.Engine, instance.DBInstanceClass)
+		if instance.DBClusterIdentifier != "" {
+			options[i] = fmt.Sprintf("%s (%s, %s, cluster %s)", instance.DBInstanceIdentifier, instance.Engine, instance.DBInstanceClass, instance.DBClusterIdentifier)
+		}
+	}
+	selection, err := utils.PromptSelection(options, "RDS Instance")
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return &instances[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid instance selection: %s", selection)
+}
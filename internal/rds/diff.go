@@ -0,0 +1,158 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// Kinds of resource `infra rds diff` compares
+const (
+	DBResourceInstance              = "instance"
+	DBResourceCluster               = "cluster"
+	DBResourceParameterGroup        = "parameter group"
+	DBResourceClusterParameterGroup = "cluster parameter group"
+)
+
+// DBResource is an instance, cluster or parameter group resolved by name, with its configuration
+// flattened into key/value pairs and the parameter groups whose parameters apply to it
+type DBResource struct {
+	Kind                  string
+	Name                  string
+	Fields                map[string]string
+	ParameterGroup        string
+	ClusterParameterGroup string
+}
+
+// Looks up name as an instance, a cluster, a parameter group and a cluster parameter group, in that order
+func ResolveDBResource(name, profile, region string) (*DBResource, error) {
+	instance, err := DescribeDBInstance(name, profile, region)
+	if err == nil {
+		resource := &DBResource{Kind: DBResourceInstance, Name: name, Fields: instance.Fields()}
+		if len(instance.DBParameterGroups) > 0 {
+			resource.ParameterGroup = instance.DBParameterGroups[0].DBParameterGroupName
+		}
+		return resource, nil
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	cluster, err := DescribeDBCluster(name, profile, region)
+	if err == nil {
+		resource := &DBResource{Kind: DBResourceCluster, Name: name, Fields: cluster.Fields(),
+			ClusterParameterGroup: cluster.DBClusterParameterGroup}
+		// Instance-level parameters and the instance class are taken from the writer
+		for _, member := range cluster.DBClusterMembers {
+			if !member.IsClusterWriter {
+				continue
+			}
+			writer, err := DescribeDBInstance(member.DBInstanceIdentifier, profile, region)
+			if err != nil {
+				return nil, err
+			}
+			resource.Fields["writer instance class"] = writer.DBInstanceClass
+			if len(writer.DBParameterGroups) > 0 {
+				resource.ParameterGroup = writer.DBParameterGroups[0].DBParameterGroupName
+				resource.Fields["parameter group"] = resource.ParameterGroup
+			}
+		}
+		return resource, nil
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	family, err := GetDBParameterGroupFamily(name, profile, region)
+	if err == nil {
+		return &DBResource{Kind: DBResourceParameterGroup, Name: name, ParameterGroup: name,
+			Fields: map[string]string{"family": family}}, nil
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	family, err = GetDBClusterParameterGroupFamily(name, profile, region)
+	if err == nil {
+		return &DBResource{Kind: DBResourceClusterParameterGroup, Name: name, ClusterParameterGroup: name,
+			Fields: map[string]string{"family": family}}, nil
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no RDS instance, cluster or parameter group named %s in %s", name, region)
+}
+
+// Reports whether an RDS error means the instance, cluster or parameter group does not exist
+func isNotFound(err error) bool {
+	var instanceNotFound *rdstypes.DBInstanceNotFoundFault
+	var clusterNotFound *rdstypes.DBClusterNotFoundFault
+	var parameterGroupNotFound *rdstypes.DBParameterGroupNotFoundFault
+	return errors.As(err, &instanceNotFound) || errors.As(err, &clusterNotFound) || errors.As(err, &parameterGroupNotFound)
+}
+
+// Flattens the configuration compared by `infra rds diff` into key/value pairs
+func (i *DBInstance) Fields() map[string]string {
+	fields := map[string]string{
+		"engine":                     i.Engine,
+		"engine version":             i.EngineVersion,
+		"instance class":             i.DBInstanceClass,
+		"storage type":               i.StorageType,
+		"allocated storage":          fmt.Sprintf("%d GiB", i.AllocatedStorage),
+		"multi-AZ":                   strconv.FormatBool(i.MultiAZ),
+		"backup retention":           fmt.Sprintf("%d days", i.BackupRetentionPeriod),
+		"backup window":              i.PreferredBackupWindow,
+		"maintenance window":         i.PreferredMaintenanceWindow,
+		"deletion protection":        strconv.FormatBool(i.DeletionProtection),
+		"auto minor version upgrade": strconv.FormatBool(i.AutoMinorVersionUpgrade),
+		"storage encrypted":          strconv.FormatBool(i.StorageEncrypted),
+		"IAM authentication":         strconv.FormatBool(i.IAMDatabaseAuthenticationEnabled),
+		"performance insights":       strconv.FormatBool(i.PerformanceInsightsEnabled),
+		"CA":                         i.CACertificateIdentifier,
+		"cluster":                    i.DBClusterIdentifier,
+	}
+	if i.MaxAllocatedStorage > 0 {
+		fields["max allocated storage"] = fmt.Sprintf("%d GiB", i.MaxAllocatedStorage)
+	}
+	if i.Iops > 0 {
+		fields["iops"] = strconv.Itoa(i.Iops)
+	}
+	if i.StorageThroughput > 0 {
+		fields["storage throughput"] = fmt.Sprintf("%d MiB/s", i.StorageThroughput)
+	}
+	if len(i.DBParameterGroups) > 0 {
+		fields["parameter group"] = i.DBParameterGroups[0].DBParameterGroupName
+	}
+	for key, value := range fields {
+		if value == "" {
+			delete(fields, key)
+		}
+	}
+	return fields
+}
+
+// Flattens the configuration compared by `infra rds diff` into key/value pairs
+func (c *DBCluster) Fields() map[string]string {
+	fields := map[string]string{
+		"engine":                  c.Engine,
+		"engine version":          c.EngineVersion,
+		"storage type":            c.StorageType,
+		"multi-AZ":                strconv.FormatBool(c.MultiAZ),
+		"instances":               strconv.Itoa(len(c.DBClusterMembers)),
+		"backup retention":        fmt.Sprintf("%d days", c.BackupRetentionPeriod),
+		"backup window":           c.PreferredBackupWindow,
+		"maintenance window":      c.PreferredMaintenanceWindow,
+		"deletion protection":     strconv.FormatBool(c.DeletionProtection),
+		"storage encrypted":       strconv.FormatBool(c.StorageEncrypted),
+		"IAM authentication":      strconv.FormatBool(c.IAMDatabaseAuthenticationEnabled),
+		"data API":                strconv.FormatBool(c.HttpEndpointEnabled),
+		"cluster parameter group": c.DBClusterParameterGroup,
+	}
+	if scaling := c.ServerlessV2ScalingConfiguration; scaling != nil {
+		fields["serverless v2 capacity"] = fmt.Sprintf("%g-%g ACUs", scaling.MinCapacity, scaling.MaxCapacity)
+	}
+	for key, value := range fields {
+		if value == "" {
+			delete(fields, key)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,78 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	pisdk "github.com/aws/aws-sdk-go-v2/service/pi"
+	pitypes "github.com/aws/aws-sdk-go-v2/service/pi/types"
+
+	"raid/infra/internal/aws"
+)
+
+// Performance Insights dimension groups and the dimensions infra reports from them
+const (
+	InsightsGroupSQL       = "db.sql_tokenized"
+	InsightsGroupWaitEvent = "db.wait_event"
+	InsightsGroupUser      = "db.user"
+
+	InsightsSQLStatement = "db.sql_tokenized.statement"
+	InsightsSQLID        = "db.sql_tokenized.id"
+	InsightsWaitName     = "db.wait_event.name"
+	InsightsWaitType     = "db.wait_event.type"
+	InsightsUserName     = "db.user.name"
+)
+
+// InsightsKey is one value of a dimension group with its average DB load (average active
+// sessions) over the window, and the load of each partition when partitioned
+type InsightsKey struct {
+	Dimensions map[string]string
+	Total      float64
+	Partitions []float64
+}
+
+// InsightsTop is the top values of a dimension group by DB load
+type InsightsTop struct {
+	Keys []InsightsKey
+	// Dimensions of each partition, in the order of InsightsKey.Partitions
+	PartitionKeys []map[string]string
+}
+
+// Returns the top values of a dimension group by average DB load between start and end, optionally
+// partitioned by another group. resourceID is the instance's DbiResourceId.
+func GetInsightsTop(resourceID, group, partitionBy string, limit int, start, end time.Time, profile, region string) (*InsightsTop, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	input := &pisdk.DescribeDimensionKeysInput{
+		ServiceType: pitypes.ServiceTypeRds,
+		Identifier:  awssdk.String(resourceID),
+		Metric:      awssdk.String("db.load.avg"),
+		StartTime:   awssdk.Time(start),
+		EndTime:     awssdk.Time(end),
+		GroupBy:     &pitypes.DimensionGroup{Group: awssdk.String(group), Limit: awssdk.Int32(int32(limit))},
+	}
+	if partitionBy != "" {
+		input.PartitionBy = &pitypes.DimensionGroup{Group: awssdk.String(partitionBy)}
+	}
+	output, err := pisdk.NewFromConfig(cfg).DescribeDimensionKeys(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Performance Insights load by %s: %v", group, err)
+	}
+
+	top := &InsightsTop{}
+	for _, key := range output.Keys {
+		top.Keys = append(top.Keys, InsightsKey{
+			Dimensions: key.Dimensions,
+			Total:      awssdk.ToFloat64(key.Total),
+			Partitions: key.Partitions,
+		})
+	}
+	for _, key := range output.PartitionKeys {
+		top.PartitionKeys = append(top.PartitionKeys, key.Dimensions)
+	}
+	return top, nil
+}
@@ -0,0 +1,90 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	rdssdk "github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// PendingMaintenanceAction is a maintenance action RDS has scheduled, or will apply, on a resource
+type PendingMaintenanceAction struct {
+	Action               string
+	Description          string
+	AutoAppliedAfterDate *time.Time
+	ForcedApplyDate      *time.Time
+	CurrentApplyDate     *time.Time
+	OptInStatus          string
+}
+
+// Returns a short description of the action and when it will be applied
+func (a PendingMaintenanceAction) Summary() string {
+	switch {
+	case a.CurrentApplyDate != nil:
+		return fmt.Sprintf("%s (applies %s)", a.Action, a.CurrentApplyDate.Format("2006-01-02"))
+	case a.ForcedApplyDate != nil:
+		return fmt.Sprintf("%s (forced %s)", a.Action, a.ForcedApplyDate.Format("2006-01-02"))
+	case a.AutoAppliedAfterDate != nil:
+		return fmt.Sprintf("%s (auto after %s)", a.Action, a.AutoAppliedAfterDate.Format("2006-01-02"))
+	}
+	return a.Action
+}
+
+// Fetches the pending maintenance actions of every instance and cluster in the region, keyed by resource ARN
+func GetPendingMaintenanceActions(profile, region string) (map[string][]PendingMaintenanceAction, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := map[string][]PendingMaintenanceAction{}
+	paginator := rdssdk.NewDescribePendingMaintenanceActionsPaginator(client, &rdssdk.DescribePendingMaintenanceActionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pending maintenance actions: %v", err)
+		}
+		for _, resource := range page.PendingMaintenanceActions {
+			arn := awssdk.ToString(resource.ResourceIdentifier)
+			for _, a := range resource.PendingMaintenanceActionDetails {
+				actions[arn] = append(actions[arn], PendingMaintenanceAction{
+					Action:               awssdk.ToString(a.Action),
+					Description:          awssdk.ToString(a.Description),
+					AutoAppliedAfterDate: a.AutoAppliedAfterDate,
+					ForcedApplyDate:      a.ForcedApplyDate,
+					CurrentApplyDate:     a.CurrentApplyDate,
+					OptInStatus:          awssdk.ToString(a.OptInStatus),
+				})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// Returns the newest minor version an engine version can be upgraded to, or "" if it is the newest
+func GetNewerMinorVersion(engine, engineVersion, profile, region string) (string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := client.DescribeDBEngineVersions(context.TODO(), &rdssdk.DescribeDBEngineVersionsInput{
+		Engine:        awssdk.String(engine),
+		EngineVersion: awssdk.String(engineVersion),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch upgrade targets of %s %s: %v", engine, engineVersion, err)
+	}
+
+	// Upgrade targets are listed oldest first
+	newest := ""
+	for _, version := range output.DBEngineVersions {
+		for _, target := range version.ValidUpgradeTarget {
+			if !awssdk.ToBool(target.IsMajorVersionUpgrade) {
+				newest = awssdk.ToString(target.EngineVersion)
+			}
+		}
+	}
+	return newest, nil
+}
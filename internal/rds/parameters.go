@@ -0,0 +1,115 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	rdssdk "github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// Parameter is a parameter of a DB or DB cluster parameter group. Source is "user" for
+// values changed from the engine default, and "engine-default" or "system" otherwise.
+type Parameter struct {
+	ParameterName  string
+	ParameterValue string
+	Source         string
+	ApplyType      string
+	IsModifiable   bool
+}
+
+// Reports whether the parameter was changed from the engine default
+func (p Parameter) IsModified() bool {
+	return p.Source == "user"
+}
+
+func parametersFromSDK(params []rdstypes.Parameter) []Parameter {
+	parameters := make([]Parameter, 0, len(params))
+	for _, p := range params {
+		parameters = append(parameters, Parameter{
+			ParameterName:  awssdk.ToString(p.ParameterName),
+			ParameterValue: awssdk.ToString(p.ParameterValue),
+			Source:         awssdk.ToString(p.Source),
+			ApplyType:      awssdk.ToString(p.ApplyType),
+			IsModifiable:   awssdk.ToBool(p.IsModifiable),
+		})
+	}
+	return parameters
+}
+
+// Fetches every parameter of a DB parameter group
+func GetDBParameters(group, profile, region string) ([]Parameter, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var parameters []Parameter
+	paginator := rdssdk.NewDescribeDBParametersPaginator(client, &rdssdk.DescribeDBParametersInput{
+		DBParameterGroupName: awssdk.String(group),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch parameters of %s: %v", group, err)
+		}
+		parameters = append(parameters, parametersFromSDK(page.Parameters)...)
+	}
+	return parameters, nil
+}
+
+// Fetches every parameter of a DB cluster parameter group
+func GetDBClusterParameters(group, profile, region string) ([]Parameter, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var parameters []Parameter
+	paginator := rdssdk.NewDescribeDBClusterParametersPaginator(client, &rdssdk.DescribeDBClusterParametersInput{
+		DBClusterParameterGroupName: awssdk.String(group),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch parameters of %s: %v", group, err)
+		}
+		parameters = append(parameters, parametersFromSDK(page.Parameters)...)
+	}
+	return parameters, nil
+}
+
+// Returns the family of a DB parameter group (e.g. postgres16), or an error if it does not exist
+func GetDBParameterGroupFamily(group, profile, region string) (string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := client.DescribeDBParameterGroups(context.TODO(), &rdssdk.DescribeDBParameterGroupsInput{
+		DBParameterGroupName: awssdk.String(group),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe parameter group %s: %w", group, err)
+	}
+	if len(output.DBParameterGroups) == 0 {
+		return "", &rdstypes.DBParameterGroupNotFoundFault{Message: awssdk.String(fmt.Sprintf("parameter group %s not found", group))}
+	}
+	return awssdk.ToString(output.DBParameterGroups[0].DBParameterGroupFamily), nil
+}
+
+// Returns the family of a DB cluster parameter group, or an error if it does not exist
+func GetDBClusterParameterGroupFamily(group, profile, region string) (string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	output, err := client.DescribeDBClusterParameterGroups(context.TODO(), &rdssdk.DescribeDBClusterParameterGroupsInput{
+		DBClusterParameterGroupName: awssdk.String(group),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe cluster parameter group %s: %w", group, err)
+	}
+	if len(output.DBClusterParameterGroups) == 0 {
+		return "", &rdstypes.DBParameterGroupNotFoundFault{Message: awssdk.String(fmt.Sprintf("cluster parameter group %s not found", group))}
+	}
+	return awssdk.ToString(output.DBClusterParameterGroups[0].DBParameterGroupFamily), nil
+}
@@ -2,16 +2,26 @@ package rds
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
-	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	rdssdk "github.com/aws/aws-sdk-go-v2/service/rds"
+
+	"raid/infra/internal/aws"
 	"raid/infra/internal/utils"
 )
 
+// Returns an RDS client for the given profile and region
+func newClient(profile, region string) (*rdssdk.Client, error) {
+	cfg, err := aws.LoadAWSConfig(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	return rdssdk.NewFromConfig(cfg), nil
+}
+
 var enginePortMap = map[string]int{
 	"MYSQL":      3306,
 	"POSTGRESQL": 5432,
@@ -58,95 +68,70 @@ func fetchRDSSelections(profile, region string) ([]string, error) {
 }
 
 func fetchRDSInstances(profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "rds", "describe-db-instances", "--query", "DBInstances[].DBInstanceIdentifier", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+	instances, err := describeDBInstances(profile, region, &rdssdk.DescribeDBInstancesInput{})
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to fetch RDS instances: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
 		return nil, fmt.Errorf("failed to fetch RDS instances: %v", err)
 	}
 
-	instances := strings.Fields(strings.TrimSpace(string(output)))
+	selections := make([]string, len(instances))
 	for i, db := range instances {
-		instances[i] = fmt.Sprintf("[RDS instance] %s", db)
+		selections[i] = fmt.Sprintf("[RDS instance] %s", db.DBInstanceIdentifier)
 	}
-	return instances, nil
+	return selections, nil
 }
 
 func fetchRDSProxies(profile, region string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "rds", "describe-db-proxies", "--query", "DBProxies[].DBProxyName", "--output", "text", "--profile", profile, "--region", region)
-	output, err := cmd.Output()
+	client, err := newClient(profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
 		return nil, err
 	}
 
-	proxies := strings.Fields(strings.TrimSpace(string(output)))
-	for i, proxy := range proxies {
-		proxies[i] = fmt.Sprintf("[RDS proxy] %s", proxy)
+	var proxies []string
+	paginator := rdssdk.NewDescribeDBProxiesPaginator(client, &rdssdk.DescribeDBProxiesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, proxy := range page.DBProxies {
+			proxies = append(proxies, fmt.Sprintf("[RDS proxy] %s", awssdk.ToString(proxy.DBProxyName)))
+		}
 	}
 	return proxies, nil
 }
 
 func fetchInstanceEndpoint(identifier, profile, region string) (string, int, error) {
 	identifier = strings.TrimPrefix(identifier, "[RDS instance] ")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "rds", "describe-db-instances", "--db-instance-identifier", identifier, "--query", "DBInstances[0].Endpoint", "--output", "json", "--profile", profile, "--region", region)
-
-	output, err := cmd.Output()
+	instance, err := DescribeDBInstance(identifier, profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", 0, fmt.Errorf("failed to fetch instance endpoint: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
 		return "", 0, fmt.Errorf("failed to fetch instance endpoint: %v", err)
 	}
-
-	var endpoint struct {
-		Address string `json:"Address"`
-		Port    int    `json:"Port"`
-	}
-	if err := json.Unmarshal(output, &endpoint); err != nil {
-		return "", 0, fmt.Errorf("failed to parse instance endpoint JSON: %v", err)
+	if instance.Endpoint.Address == "" {
+		return "", 0, fmt.Errorf("RDS instance %s has no endpoint yet (status %s)", identifier, instance.DBInstanceStatus)
 	}
-	return endpoint.Address, endpoint.Port, nil
+	return instance.Endpoint.Address, instance.Endpoint.Port, nil
 }
 
 func fetchProxyEndpoint(identifier, profile, region string) (string, int, error) {
 	identifier = strings.TrimPrefix(identifier, "[RDS proxy] ")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "aws", "rds", "describe-db-proxies", "--db-proxy-name", identifier, "--query", "DBProxies[0].[Endpoint, EngineFamily]", "--output", "json", "--profile", profile, "--region", region)
-
-	output, err := cmd.Output()
+	client, err := newClient(profile, region)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", 0, fmt.Errorf("failed to fetch proxy endpoint: %s", strings.TrimSpace(string(exitErr.Stderr)))
-		}
-		return "", 0, fmt.Errorf("failed to fetch proxy endpoint: %v", err)
+		return "", 0, err
 	}
-
-	var proxyResult []interface{}
-	if err := json.Unmarshal(output, &proxyResult); err != nil {
-		return "", 0, fmt.Errorf("failed to parse proxy endpoint JSON: %v", err)
+	output, err := client.DescribeDBProxies(context.TODO(), &rdssdk.DescribeDBProxiesInput{DBProxyName: awssdk.String(identifier)})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch proxy endpoint: %v", err)
 	}
-	if len(proxyResult) != 2 {
-		return "", 0, fmt.Errorf("unexpected proxy endpoint format")
+	if len(output.DBProxies) == 0 {
+		return "", 0, fmt.Errorf("RDS proxy %s not found", identifier)
 	}
 
-	address, ok := proxyResult[0].(string)
-	if !ok || address == "" {
+	address := awssdk.ToString(output.DBProxies[0].Endpoint)
+	if address == "" {
 		return "", 0, fmt.Errorf("invalid or missing proxy endpoint address")
 	}
-	engineFamily, ok := proxyResult[1].(string)
-	if !ok || engineFamily == "" {
+	engineFamily := awssdk.ToString(output.DBProxies[0].EngineFamily)
+	if engineFamily == "" {
 		return "", 0, fmt.Errorf("invalid or missing proxy engine family")
 	}
 	port, ok := enginePortMap[strings.ToUpper(engineFamily)]
@@ -0,0 +1,479 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	rdssdk "github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+
+	"raid/infra/internal/utils"
+)
+
+// How often snapshot, instance and cluster status is polled while waiting
+const rdsPollInterval = 15 * time.Second
+
+// Snapshot is a manual or automated snapshot of an RDS instance or Aurora cluster
+type Snapshot struct {
+	Identifier       string
+	Arn              string
+	SourceIdentifier string
+	IsCluster        bool
+	Status           string
+	SnapshotType     string
+	Engine           string
+	CreateTime       time.Time
+	AllocatedStorage int
+	PercentProgress  int
+	Encrypted        bool
+	KmsKeyID         string
+}
+
+func snapshotFromSDK(s *rdstypes.DBSnapshot) Snapshot {
+	return Snapshot{
+		Identifier: awssdk.ToString(s.DBSnapshotIdentifier), Arn: awssdk.ToString(s.DBSnapshotArn),
+		SourceIdentifier: awssdk.ToString(s.DBInstanceIdentifier), Status: awssdk.ToString(s.Status),
+		SnapshotType: awssdk.ToString(s.SnapshotType), Engine: awssdk.ToString(s.Engine), CreateTime: awssdk.ToTime(s.SnapshotCreateTime),
+		AllocatedStorage: int(awssdk.ToInt32(s.AllocatedStorage)), PercentProgress: int(awssdk.ToInt32(s.PercentProgress)),
+		Encrypted: awssdk.ToBool(s.Encrypted), KmsKeyID: awssdk.ToString(s.KmsKeyId),
+	}
+}
+
+func clusterSnapshotFromSDK(s *rdstypes.DBClusterSnapshot) Snapshot {
+	return Snapshot{
+		Identifier: awssdk.ToString(s.DBClusterSnapshotIdentifier), Arn: awssdk.ToString(s.DBClusterSnapshotArn),
+		SourceIdentifier: awssdk.ToString(s.DBClusterIdentifier), IsCluster: true, Status: awssdk.ToString(s.Status),
+		SnapshotType: awssdk.ToString(s.SnapshotType), Engine: awssdk.ToString(s.Engine), CreateTime: awssdk.ToTime(s.SnapshotCreateTime),
+		AllocatedStorage: int(awssdk.ToInt32(s.AllocatedStorage)), PercentProgress: int(awssdk.ToInt32(s.PercentProgress)),
+		Encrypted: awssdk.ToBool(s.StorageEncrypted), KmsKeyID: awssdk.ToString(s.KmsKeyId),
+	}
+}
+
+var invalidSnapshotChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// Returns a snapshot identifier following the <source>-<label>-<yyyymmdd-hhmmss> convention.
+// Identifiers may only contain letters, digits and single hyphens.
+func SnapshotName(source, label string, at time.Time) string {
+	if label == "" {
+		label = "infra"
+	}
+	name := fmt.Sprintf("%s-%s-%s", source, label, at.UTC().Format("20060102-150405"))
+	name = invalidSnapshotChars.ReplaceAllString(name, "-")
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	return strings.Trim(name, "-")
+}
+
+// Starts a manual snapshot of an instance or cluster
+func CreateSnapshot(target *DBTarget, snapshotID, profile, region string) (*Snapshot, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if target.IsCluster {
+		output, err := client.CreateDBClusterSnapshot(context.TODO(), &rdssdk.CreateDBClusterSnapshotInput{
+			DBClusterIdentifier:         awssdk.String(target.Identifier),
+			DBClusterSnapshotIdentifier: awssdk.String(snapshotID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cluster snapshot: %v", err)
+		}
+		s := clusterSnapshotFromSDK(output.DBClusterSnapshot)
+		return &s, nil
+	}
+
+	output, err := client.CreateDBSnapshot(context.TODO(), &rdssdk.CreateDBSnapshotInput{
+		DBInstanceIdentifier: awssdk.String(target.Identifier),
+		DBSnapshotIdentifier: awssdk.String(snapshotID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %v", err)
+	}
+	s := snapshotFromSDK(output.DBSnapshot)
+	return &s, nil
+}
+
+// Fetches a snapshot by identifier or ARN
+func DescribeSnapshot(isCluster bool, snapshotID, profile, region string) (*Snapshot, error) {
+	if isCluster {
+		snapshots, err := describeClusterSnapshots(profile, region, &rdssdk.DescribeDBClusterSnapshotsInput{
+			DBClusterSnapshotIdentifier: awssdk.String(snapshotID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe cluster snapshot: %v", err)
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("cluster snapshot %s not found", snapshotID)
+		}
+		return &snapshots[0], nil
+	}
+
+	snapshots, err := describeSnapshots(profile, region, &rdssdk.DescribeDBSnapshotsInput{DBSnapshotIdentifier: awssdk.String(snapshotID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe snapshot: %v", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("snapshot %s not found", snapshotID)
+	}
+	return &snapshots[0], nil
+}
+
+// Lists the manual snapshots of an instance or cluster, newest first
+func ListManualSnapshots(target *DBTarget, profile, region string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	var err error
+	if target.IsCluster {
+		snapshots, err = describeClusterSnapshots(profile, region, &rdssdk.DescribeDBClusterSnapshotsInput{
+			DBClusterIdentifier: awssdk.String(target.Identifier),
+			SnapshotType:        awssdk.String("manual"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cluster snapshots: %v", err)
+		}
+	} else {
+		snapshots, err = describeSnapshots(profile, region, &rdssdk.DescribeDBSnapshotsInput{
+			DBInstanceIdentifier: awssdk.String(target.Identifier),
+			SnapshotType:         awssdk.String("manual"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %v", err)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreateTime.After(snapshots[j].CreateTime)
+	})
+	return snapshots, nil
+}
+
+// Fetches every instance snapshot matching a describe-db-snapshots request
+func describeSnapshots(profile, region string, input *rdssdk.DescribeDBSnapshotsInput) ([]Snapshot, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	paginator := rdssdk.NewDescribeDBSnapshotsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.DBSnapshots {
+			snapshots = append(snapshots, snapshotFromSDK(&page.DBSnapshots[i]))
+		}
+	}
+	return snapshots, nil
+}
+
+// Fetches every cluster snapshot matching a describe-db-cluster-snapshots request
+func describeClusterSnapshots(profile, region string, input *rdssdk.DescribeDBClusterSnapshotsInput) ([]Snapshot, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	paginator := rdssdk.NewDescribeDBClusterSnapshotsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.DBClusterSnapshots {
+			snapshots = append(snapshots, clusterSnapshotFromSDK(&page.DBClusterSnapshots[i]))
+		}
+	}
+	return snapshots, nil
+}
+
+// Prompts the user to select one of the manual snapshots of an instance or cluster
+func SelectSnapshot(target *DBTarget, profile, region string) (*Snapshot, error) {
+	snapshots, err := ListManualSnapshots(target, profile, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no manual snapshots of %s", target)
+	}
+
+	options := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		options[i] = fmt.Sprintf("%s (%s, %s)", s.Identifier, s.CreateTime.Local().Format("2006-01-02 15:04"), s.Status)
+	}
+	selection, err := utils.PromptSelection(options, "Snapshot")
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i] == selection {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid snapshot selection: %s", selection)
+}
+
+// Deletes a manual snapshot
+func DeleteSnapshot(snapshot *Snapshot, profile, region string) error {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return err
+	}
+	if snapshot.IsCluster {
+		_, err = client.DeleteDBClusterSnapshot(context.TODO(), &rdssdk.DeleteDBClusterSnapshotInput{
+			DBClusterSnapshotIdentifier: awssdk.String(snapshot.Identifier),
+		})
+	} else {
+		_, err = client.DeleteDBSnapshot(context.TODO(), &rdssdk.DeleteDBSnapshotInput{
+			DBSnapshotIdentifier: awssdk.String(snapshot.Identifier),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %s: %v", snapshot.Identifier, err)
+	}
+	return nil
+}
+
+// Waits until a snapshot is available, printing its progress
+func WaitForSnapshot(isCluster bool, snapshotID, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		snapshot, err := DescribeSnapshot(isCluster, snapshotID, profile, region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] %s: %s, %d%%\n", time.Now().Format("15:04:05"), snapshotID, snapshot.Status, snapshot.PercentProgress)
+
+		switch snapshot.Status {
+		case "available":
+			return nil
+		case "failed", "incompatible-restore", "incompatible-parameters":
+			return fmt.Errorf("snapshot %s is %s", snapshotID, snapshot.Status)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for snapshot %s", timeout, snapshotID)
+		}
+		time.Sleep(rdsPollInterval)
+	}
+}
+
+// Shares a manual snapshot with another AWS account so it can be copied there
+func ShareSnapshot(snapshot *Snapshot, accountID, profile, region string) error {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return err
+	}
+	if snapshot.IsCluster {
+		_, err = client.ModifyDBClusterSnapshotAttribute(context.TODO(), &rdssdk.ModifyDBClusterSnapshotAttributeInput{
+			DBClusterSnapshotIdentifier: awssdk.String(snapshot.Identifier),
+			AttributeName:               awssdk.String("restore"),
+			ValuesToAdd:                 []string{accountID},
+		})
+	} else {
+		_, err = client.ModifyDBSnapshotAttribute(context.TODO(), &rdssdk.ModifyDBSnapshotAttributeInput{
+			DBSnapshotIdentifier: awssdk.String(snapshot.Identifier),
+			AttributeName:        awssdk.String("restore"),
+			ValuesToAdd:          []string{accountID},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to share snapshot %s with %s: %v", snapshot.Identifier, accountID, err)
+	}
+	return nil
+}
+
+// Copies a snapshot into the region of the given profile and region, re-encrypting it with kmsKeyID
+// if set. sourceRegion is the region of the source snapshot, whose ARN must be used across regions
+// and accounts.
+func CopySnapshot(source *Snapshot, sourceRegion, targetID, kmsKeyID, profile, region string) (*Snapshot, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	var kmsKey, fromRegion *string
+	if kmsKeyID != "" {
+		kmsKey = awssdk.String(kmsKeyID)
+	}
+	// Setting the source region has the SDK presign the cross-region copy request
+	if sourceRegion != region {
+		fromRegion = awssdk.String(sourceRegion)
+	}
+
+	if source.IsCluster {
+		output, err := client.CopyDBClusterSnapshot(context.TODO(), &rdssdk.CopyDBClusterSnapshotInput{
+			SourceDBClusterSnapshotIdentifier: awssdk.String(source.Arn),
+			TargetDBClusterSnapshotIdentifier: awssdk.String(targetID),
+			CopyTags:                          awssdk.Bool(true),
+			KmsKeyId:                          kmsKey,
+			SourceRegion:                      fromRegion,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy cluster snapshot: %v", err)
+		}
+		s := clusterSnapshotFromSDK(output.DBClusterSnapshot)
+		return &s, nil
+	}
+
+	output, err := client.CopyDBSnapshot(context.TODO(), &rdssdk.CopyDBSnapshotInput{
+		SourceDBSnapshotIdentifier: awssdk.String(source.Arn),
+		TargetDBSnapshotIdentifier: awssdk.String(targetID),
+		CopyTags:                   awssdk.Bool(true),
+		KmsKeyId:                   kmsKey,
+		SourceRegion:               fromRegion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy snapshot: %v", err)
+	}
+	s := snapshotFromSDK(output.DBSnapshot)
+	return &s, nil
+}
+
+// RestoreSettings is where and how a snapshot is restored, normally copied from the snapshot's source
+type RestoreSettings struct {
+	Identifier       string
+	InstanceClass    string
+	SubnetGroup      string
+	SecurityGroupIDs []string
+}
+
+// Returns the settings of an instance or cluster to restore its snapshots with. For a cluster,
+// the instance class is that of its writer.
+func RestoreSettingsFrom(target *DBTarget, profile, region string) (*RestoreSettings, error) {
+	if !target.IsCluster {
+		instance, err := DescribeDBInstance(target.Identifier, profile, region)
+		if err != nil {
+			return nil, err
+		}
+		return &RestoreSettings{
+			InstanceClass:    instance.DBInstanceClass,
+			SubnetGroup:      instance.DBSubnetGroup.DBSubnetGroupName,
+			SecurityGroupIDs: securityGroupIDs(instance.VpcSecurityGroups),
+		}, nil
+	}
+
+	cluster, err := DescribeDBCluster(target.Identifier, profile, region)
+	if err != nil {
+		return nil, err
+	}
+	settings := &RestoreSettings{
+		SubnetGroup:      cluster.DBSubnetGroup,
+		SecurityGroupIDs: securityGroupIDs(cluster.VpcSecurityGroups),
+	}
+	for _, member := range cluster.DBClusterMembers {
+		if !member.IsClusterWriter {
+			continue
+		}
+		writer, err := DescribeDBInstance(member.DBInstanceIdentifier, profile, region)
+		if err != nil {
+			return nil, err
+		}
+		settings.InstanceClass = writer.DBInstanceClass
+	}
+	if settings.InstanceClass == "" {
+		return nil, fmt.Errorf("cluster %s has no writer instance to copy the instance class from", target.Identifier)
+	}
+	return settings, nil
+}
+
+// Restores an instance snapshot to a new instance
+func RestoreDBInstance(snapshot *Snapshot, settings RestoreSettings, profile, region string) error {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return err
+	}
+	_, err = client.RestoreDBInstanceFromDBSnapshot(context.TODO(), &rdssdk.RestoreDBInstanceFromDBSnapshotInput{
+		DBInstanceIdentifier: awssdk.String(settings.Identifier),
+		DBSnapshotIdentifier: awssdk.String(snapshot.Identifier),
+		DBInstanceClass:      awssdk.String(settings.InstanceClass),
+		DBSubnetGroupName:    awssdk.String(settings.SubnetGroup),
+		VpcSecurityGroupIds:  settings.SecurityGroupIDs,
+		CopyTagsToSnapshot:   awssdk.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot %s: %v", snapshot.Identifier, err)
+	}
+	return nil
+}
+
+// Restores a cluster snapshot to a new Aurora cluster. Restoring a cluster creates no instances,
+// so a writer named <identifier>-1 is added and its identifier returned.
+func RestoreDBCluster(snapshot *Snapshot, settings RestoreSettings, profile, region string) (string, error) {
+	client, err := newClient(profile, region)
+	if err != nil {
+		return "", err
+	}
+	_, err = client.RestoreDBClusterFromSnapshot(context.TODO(), &rdssdk.RestoreDBClusterFromSnapshotInput{
+		DBClusterIdentifier: awssdk.String(settings.Identifier),
+		SnapshotIdentifier:  awssdk.String(snapshot.Identifier),
+		Engine:              awssdk.String(snapshot.Engine),
+		DBSubnetGroupName:   awssdk.String(settings.SubnetGroup),
+		VpcSecurityGroupIds: settings.SecurityGroupIDs,
+		CopyTagsToSnapshot:  awssdk.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to restore cluster snapshot %s: %v", snapshot.Identifier, err)
+	}
+
+	writer := settings.Identifier + "-1"
+	_, err = client.CreateDBInstance(context.TODO(), &rdssdk.CreateDBInstanceInput{
+		DBInstanceIdentifier: awssdk.String(writer),
+		DBClusterIdentifier:  awssdk.String(settings.Identifier),
+		Engine:               awssdk.String(snapshot.Engine),
+		DBInstanceClass:      awssdk.String(settings.InstanceClass),
+	})
+	if err != nil {
+		return "", fmt.Errorf("restored cluster %s but failed to create its writer instance: %v", settings.Identifier, err)
+	}
+	return writer, nil
+}
+
+// Waits until an RDS instance is available, printing its status
+func WaitForDBInstanceAvailable(identifier, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		instance, err := DescribeDBInstance(identifier, profile, region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] %s: %s\n", time.Now().Format("15:04:05"), identifier, instance.DBInstanceStatus)
+
+		switch instance.DBInstanceStatus {
+		case "available":
+			return nil
+		case "failed", "incompatible-restore", "incompatible-parameters", "incompatible-network", "restore-error":
+			return fmt.Errorf("RDS instance %s is %s", identifier, instance.DBInstanceStatus)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for RDS instance %s", timeout, identifier)
+		}
+		time.Sleep(rdsPollInterval)
+	}
+}
+
+// Waits until an Aurora cluster is available, printing its status
+func WaitForDBClusterAvailable(identifier, profile, region string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		cluster, err := DescribeDBCluster(identifier, profile, region)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] %s: %s\n", time.Now().Format("15:04:05"), identifier, cluster.Status)
+
+		switch cluster.Status {
+		case "available":
+			return nil
+		case "failed", "inaccessible-encryption-credentials", "incompatible-restore":
+			return fmt.Errorf("RDS cluster %s is %s", identifier, cluster.Status)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for RDS cluster %s", timeout, identifier)
+		}
+		time.Sleep(rdsPollInterval)
+	}
+}
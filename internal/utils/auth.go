@@ -19,9 +19,9 @@ func Login() (string, string, error) {
 	}
 
 	if len(profiles) == 0 {
-		fmt.Println("No AWS profiles found. Please configure a new profile using 'aws configure sso'.")
+		fmt.Fprintln(os.Stderr, "No AWS profiles found. Please configure a new profile using 'aws configure sso'.")
 		cmd := exec.Command("aws", "configure", "sso")
-		cmd.Stdout = os.Stdout
+		cmd.Stdout = os.Stderr
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
 			return "", "", fmt.Errorf("failed to configure AWS SSO: %v", err)
@@ -87,15 +87,15 @@ func handleExpiredCredentials(profile string) error {
 	cmd := exec.CommandContext(ctx, "aws", "sts", "get-caller-identity", "--profile", profile)
 	err := cmd.Run()
 	if err != nil {
-		fmt.Printf("Credentials for profile '%s' have expired or are invalid. Logging in...\n", profile)
+		fmt.Fprintf(os.Stderr, "Credentials for profile '%s' have expired or are invalid. Logging in...\n", profile)
 		loginCmd := exec.Command("aws", "sso", "login", "--profile", profile)
-		loginCmd.Stdout = os.Stdout
+		loginCmd.Stdout = os.Stderr
 		loginCmd.Stderr = os.Stderr
 		err = loginCmd.Run()
 		if err != nil {
 			return fmt.Errorf("failed to log in to AWS SSO: %v", err)
 		}
-		fmt.Println("AWS SSO login successful.")
+		fmt.Fprintln(os.Stderr, "AWS SSO login successful.")
 	}
 	return nil
 }
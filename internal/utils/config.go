@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Returns the directory infra keeps local state in (~/.infra), creating it if needed
+func InfraDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	dir := filepath.Join(homeDir, ".infra")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	return dir, nil
+}
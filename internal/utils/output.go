@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"io"
+	"sync"
+)
+
+// PrefixWriter prefixes every line written to it. Writers sharing a mutex can
+// write to the same output concurrently without interleaving partial lines.
+type PrefixWriter struct {
+	out         io.Writer
+	mu          *sync.Mutex
+	prefix      string
+	atLineStart bool
+}
+
+// Returns a PrefixWriter that writes to out, guarded by mu
+func NewPrefixWriter(out io.Writer, mu *sync.Mutex, prefix string) *PrefixWriter {
+	return &PrefixWriter{out: out, mu: mu, prefix: prefix, atLineStart: true}
+}
+
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var buf []byte
+	for _, b := range p {
+		if w.atLineStart {
+			buf = append(buf, w.prefix...)
+			w.atLineStart = false
+		}
+		buf = append(buf, b)
+		if b == '\n' {
+			w.atLineStart = true
+		}
+	}
+	if _, err := w.out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Returns value, or fallback when value is empty
+func ValueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Returns value, or "-" when it is empty, for table cells
+func ValueOrDash(value string) string {
+	return ValueOrDefault(value, "-")
+}
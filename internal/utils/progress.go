@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Progress prints a single, periodically refreshed progress line to stderr
+type Progress struct {
+	label     string
+	total     int64
+	done      int64
+	lastPrint time.Time
+}
+
+// Returns a Progress for a transfer of total bytes, or of an unknown size if total is 0
+func NewProgress(label string, total int64) *Progress {
+	return &Progress{label: label, total: total}
+}
+
+// Records n more bytes and refreshes the progress line at most ten times a second
+func (p *Progress) Add(n int) {
+	p.done += int64(n)
+	if time.Since(p.lastPrint) >= 100*time.Millisecond {
+		p.print()
+	}
+}
+
+// Returns a reader that records the bytes read from r
+func (p *Progress) Reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, progress: p}
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *Progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.progress.Add(n)
+	return n, err
+}
+
+// Prints the final progress line and ends it
+func (p *Progress) Finish() {
+	p.print()
+	fmt.Fprintln(os.Stderr)
+}
+
+func (p *Progress) print() {
+	p.lastPrint = time.Now()
+	if p.total <= 0 {
+		fmt.Fprintf(os.Stderr, "\r%s: %s", p.label, FormatBytes(p.done))
+		return
+	}
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.done) * 100 / float64(p.total)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s: %5.1f%% (%s / %s)", p.label, percent, FormatBytes(p.done), FormatBytes(p.total))
+}
+
+// Formats a byte count with a binary unit suffix
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
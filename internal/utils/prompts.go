@@ -12,26 +12,27 @@ import (
 	"time"
 )
 
-// Prompts the user to select from a list of options
+// Prompts the user to select from a list of options. Prompts are written to stderr so that
+// stdout carries only a command's output.
 func PromptSelection(options []string, taskName ...string) (string, error) {
 	attempts := 0
 
 	for attempts < 3 {
 		// Display the options for user reference
 		if len(taskName) > 0 && taskName[0] != "" {
-			fmt.Printf("Please select %s from the following options:\n", taskName[0])
+			fmt.Fprintf(os.Stderr, "Please select %s from the following options:\n", taskName[0])
 		} else {
-			fmt.Println("Please select from the following options:")
+			fmt.Fprintln(os.Stderr, "Please select from the following options:")
 		}
 		for i, option := range options {
-			fmt.Printf("[%d] %s\n", i+1, option)
+			fmt.Fprintf(os.Stderr, "[%d] %s\n", i+1, option)
 		}
 
-		fmt.Print("Enter the number of your choice: ")
+		fmt.Fprint(os.Stderr, "Enter the number of your choice: ")
 		reader := bufio.NewReader(os.Stdin)
 		choice, err := reader.ReadString('\n')
 		if err != nil {
-			fmt.Printf("Failed to read input: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
 			attempts++
 			continue
 		}
@@ -42,7 +43,7 @@ func PromptSelection(options []string, taskName ...string) (string, error) {
 		}
 
 		attempts++
-		fmt.Printf("Invalid choice. You have %d attempt(s) remaining.\n", 3-attempts)
+		fmt.Fprintf(os.Stderr, "Invalid choice. You have %d attempt(s) remaining.\n", 3-attempts)
 	}
 
 	// If the user fails 3 times, exit with an error
@@ -55,7 +56,7 @@ func PromptLocalPortNumber() (int, error) {
 	attempts := 0
 
 	for attempts < 3 {
-		fmt.Println("Enter a local port number for port forwarding (1024–65535):")
+		fmt.Fprintln(os.Stderr, "Enter a local port number for port forwarding (1024–65535):")
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			return 0, fmt.Errorf("failed to read input: %v", err)
@@ -68,7 +69,7 @@ func PromptLocalPortNumber() (int, error) {
 			return port, nil
 		}
 
-		fmt.Println("Invalid port number. Please enter a number between 1024 and 65535.")
+		fmt.Fprintln(os.Stderr, "Invalid port number. Please enter a number between 1024 and 65535.")
 		attempts++
 	}
 
@@ -124,7 +125,7 @@ func PromptInput(prompt string, validate func(input string) error, defaultValue
 
 	for {
 		// Display the prompt
-		fmt.Print(basePrompt)
+		fmt.Fprint(os.Stderr, basePrompt)
 
 		// Read user input
 		input, err := reader.ReadString('\n')
@@ -143,7 +144,7 @@ func PromptInput(prompt string, validate func(input string) error, defaultValue
 		// Validate input if a validation function is provided
 		if validate != nil {
 			if err := validate(input); err != nil {
-				fmt.Printf("Invalid input: %s. Please try again.\n", err)
+				fmt.Fprintf(os.Stderr, "Invalid input: %s. Please try again.\n", err)
 				continue
 			}
 		}
@@ -154,11 +155,11 @@ func PromptInput(prompt string, validate func(input string) error, defaultValue
 
 // confirmPrompt displays a confirmation prompt and returns true if the user confirms.
 func ConfirmPrompt(message string) bool {
-	fmt.Print(message + " ")
+	fmt.Fprint(os.Stderr, message + " ")
 	var response string
 	_, err := fmt.Scanln(&response)
 	if err != nil {
-		fmt.Println("Error reading input:", err)
+		fmt.Fprintln(os.Stderr, "Error reading input:", err)
 		return false
 	}
 	response = strings.ToLower(strings.TrimSpace(response))
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats for reports
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// Returns an error unless format is one of the supported output formats
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputCSV:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q (use %s, %s or %s)", format, OutputTable, OutputJSON, OutputCSV)
+}
+
+// Writes records as an aligned table or CSV with the given header, or as indented JSON. JSON
+// encodes data, which should hold the same records with their native types.
+func WriteRecords(w io.Writer, format string, header []string, rows [][]string, data interface{}) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case OutputCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				if cell == "" {
+					cell = "-"
+				}
+				// Tabs and newlines would break the alignment
+				cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+	return ValidateOutputFormat(format)
+}
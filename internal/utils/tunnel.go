@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// How long to wait for a tunnel's local port to accept connections
+const tunnelStartTimeout = 60 * time.Second
+
+// Tunnel is an SSM port forwarding session to a remote host running in the background,
+// for commands that need the forwarded port rather than an interactive session
+type Tunnel struct {
+	LocalPort int
+	cmd       *exec.Cmd
+	output    *bytes.Buffer
+	done      chan error
+}
+
+// Starts forwarding a free local port to remoteHost:remotePort through the SSM target (an EC2
+// instance ID or an ecs:<cluster>_<task>_<runtime ID> container) and waits until it accepts connections
+func StartTunnel(target, remoteHost string, remotePort int, profile, region string) (*Tunnel, error) {
+	localPort, err := freeLocalPort()
+	if err != nil {
+		return nil, err
+	}
+
+	output := &bytes.Buffer{}
+	cmd := exec.Command("aws", "ssm", "start-session",
+		"--target", target,
+		"--document-name", "AWS-StartPortForwardingSessionToRemoteHost",
+		"--parameters", fmt.Sprintf(`{"host":["%s"],"portNumber":["%d"],"localPortNumber":["%d"]}`, remoteHost, remotePort, localPort),
+		"--profile", profile, "--region", region)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start SSM session: %v", err)
+	}
+
+	t := &Tunnel{LocalPort: localPort, cmd: cmd, output: output, done: make(chan error, 1)}
+	go func() { t.done <- cmd.Wait() }()
+
+	deadline := time.Now().Add(tunnelStartTimeout)
+	for {
+		select {
+		case err := <-t.done:
+			return nil, fmt.Errorf("SSM session ended before the tunnel was ready (%v): %s", err, strings.TrimSpace(output.String()))
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", localPort), time.Second)
+		if err == nil {
+			conn.Close()
+			return t, nil
+		}
+		if time.Now().After(deadline) {
+			t.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for the tunnel on port %d: %s", tunnelStartTimeout, localPort, strings.TrimSpace(output.String()))
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Ends the SSM session, killing it if it does not exit after being interrupted
+func (t *Tunnel) Close() {
+	if t.cmd.Process == nil {
+		return
+	}
+	t.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-t.done:
+	case <-time.After(5 * time.Second):
+		t.cmd.Process.Kill()
+		<-t.done
+	}
+}
+
+// Returns a local port nothing is listening on
+func freeLocalPort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free local port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}